@@ -3,7 +3,9 @@ module mkmgo-momworks
 go 1.23.0
 
 require (
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/xuri/excelize/v2 v2.8.1
+	golang.org/x/text v0.14.0
 	gopkg.in/yaml.v2 v2.4.0
 )
 
@@ -19,6 +21,5 @@ require (
 	github.com/xuri/nfp v0.0.0-20230919160717-d98342af3f05 // indirect
 	golang.org/x/crypto v0.21.0 // indirect
 	golang.org/x/net v0.23.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 )
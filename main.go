@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"mkmgo-momworks/sasaranimunisasi"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"gopkg.in/yaml.v2"
 )
@@ -15,9 +20,24 @@ type Config struct {
 	SasaranImunisasiCfg sasaranimunisasi.SasaranImunisasiConfig `yaml:"sasaran_imunisasi_config"` // Configuration specific to SasaranImunisasiService
 }
 
-// LoadConfig reads the configuration from a YAML file and returns a Config struct.
-func LoadConfig() (*Config, error) {
-	file, err := os.Open("config.yaml")
+// consts for the command line
+const (
+	defaultConfigPath = "config.yaml"
+	serveCommand      = "serve"    // starts the HTTP server, the default when no command is given
+	generateCommand   = "generate" // generates the files of one source file or a folder of source files
+	watchCommand      = "watch"    // generates the files of every source file dropped into the inbox folder
+	usage             = `Usage:
+  momworks [serve] [--config config.yaml]
+  momworks generate --input export.xlsx|folder [--sheet Sheet1] [--type bayi|baduta|semua]
+                    [--tanggal-acuan YYYY-MM-DD] [--pisah-posyandu sheet|zip] [--status label|angka]
+                    [--layout lengkap|ringkas] [--out dir] [--pengingat] [--undangan] [--pdf] [--config config.yaml]
+  momworks watch [--config config.yaml]
+`
+)
+
+// LoadConfig reads the configuration from the YAML file at the given path and returns a Config struct.
+func LoadConfig(path string) (*Config, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("error opening file: %w", err)
 	}
@@ -33,14 +53,40 @@ func LoadConfig() (*Config, error) {
 }
 
 func main() {
+	command, args := serveCommand, os.Args[1:]
+	if len(args) > 0 && (args[0] == serveCommand || args[0] == generateCommand || args[0] == watchCommand) {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case generateCommand:
+		os.Exit(runGenerate(args))
+	case watchCommand:
+		runWatch(args)
+	default:
+		runServe(args)
+	}
+}
+
+// runServe starts the HTTP server generating files from uploads.
+func runServe(args []string) {
+	flags := flag.NewFlagSet(serveCommand, flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), usage) }
+	configPath := flags.String("config", defaultConfigPath, "path of the configuration file")
+	flags.Parse(args)
+
 	// Load the configuration
-	cfg, err := LoadConfig()
+	cfg, err := LoadConfig(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
 	// Initialize the handler with Sasaran Imunisasi services
-	sasaranImunisasiHandler := sasaranimunisasi.NewSasaranImunisasiHandler(sasaranimunisasi.NewSasaranImunisasiService(&cfg.SasaranImunisasiCfg))
+	svc, err := sasaranimunisasi.NewSasaranImunisasiService(&cfg.SasaranImunisasiCfg)
+	if err != nil {
+		log.Fatalf("Invalid config: %v", err)
+	}
+	sasaranImunisasiHandler := sasaranimunisasi.NewSasaranImunisasiHandler(svc)
 
 	// Define the route and handler for generating files
 	http.HandleFunc("/momworks/sasaran/imunisasi", sasaranImunisasiHandler.GenerateFileHandler)
@@ -50,3 +96,98 @@ func main() {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
+
+// runGenerate generates the files of the source file or folder of source files given on the command line
+// and prints a summary line per source file. It returns 0 when every file is generated, 1 when any fails
+// and 2 on invalid arguments.
+func runGenerate(args []string) int {
+	flags := flag.NewFlagSet(generateCommand, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), usage) }
+	input := flags.String("input", "", "source xlsx or CSV file, or a folder of source files")
+	configPath := flags.String("config", defaultConfigPath, "path of the configuration file")
+	var opts sasaranimunisasi.BatchOptions
+	flags.StringVar(&opts.SheetName, "sheet", "", "sheet of the source file, detected from the headers when empty or not found")
+	flags.StringVar(&opts.SasaranType, "type", "", "sasaran type: bayi, baduta or semua, children are classified by age when empty")
+	flags.StringVar(&opts.TanggalAcuan, "tanggal-acuan", "", "reference date in the format YYYY-MM-DD, defaults to today")
+	flags.StringVar(&opts.PisahPosyandu, "pisah-posyandu", "", "sheet or zip to split the list per posyandu")
+	flags.StringVar(&opts.Status, "status", "", "label or angka for the status columns, defaults to status_imunisasi of the config")
+	flags.StringVar(&opts.Layout, "layout", "", "lengkap or ringkas for the sasaran sheets and PDF, defaults to layout of the config")
+	flags.StringVar(&opts.OutputDir, "out", ".", "folder the generated files are written to")
+	flags.BoolVar(&opts.Pengingat, "pengingat", false, "also write the reminder messages of the parents as a CSV of phone and message")
+	flags.BoolVar(&opts.Undangan, "undangan", false, "also write the invitation slips of the parents as a PDF")
+	flags.BoolVar(&opts.Pdf, "pdf", false, "also write the sasaran list as a print-ready PDF")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *input == "" {
+		fmt.Fprintln(os.Stderr, "missing --input")
+		flags.Usage()
+		return 2
+	}
+
+	cfg, err := LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		return 1
+	}
+	svc, err := sasaranimunisasi.NewSasaranImunisasiService(&cfg.SasaranImunisasiCfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %v\n", err)
+		return 1
+	}
+	inputPaths, err := sasaranimunisasi.ListSourceFiles(*input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read input: %v\n", err)
+		return 1
+	}
+	if len(inputPaths) == 0 {
+		fmt.Fprintf(os.Stderr, "No xlsx or CSV file found in %s\n", *input)
+		return 1
+	}
+
+	failed := 0
+	for _, result := range svc.RunBatch(inputPaths, opts) {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("FAILED %s: %v\n", result.InputPath, result.Err)
+			continue
+		}
+		fmt.Printf("OK     %s -> %s (%d sasaran of %d source rows, %d rejected)\n", result.InputPath, result.OutputPath,
+			result.Summary.SasaranRows, result.Summary.SourceRows, result.Summary.RejectedRows)
+	}
+
+	fmt.Printf("Generated %d of %d files, %d failed\n", len(inputPaths)-failed, len(inputPaths), failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runWatch polls the inbox folder of the watch config until the process is interrupted.
+func runWatch(args []string) {
+	flags := flag.NewFlagSet(watchCommand, flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), usage) }
+	configPath := flags.String("config", defaultConfigPath, "path of the configuration file")
+	flags.Parse(args)
+
+	cfg, err := LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	svc, err := sasaranimunisasi.NewSasaranImunisasiService(&cfg.SasaranImunisasiCfg)
+	if err != nil {
+		log.Fatalf("Invalid config: %v", err)
+	}
+	watcher, err := svc.NewWatcher(cfg.SasaranImunisasiCfg.Watch)
+	if err != nil {
+		log.Fatalf("Failed to start watching: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := watcher.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		log.Fatalf("Watching failed: %v", err)
+	}
+	log.Println("Stopped watching")
+}
@@ -0,0 +1,97 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MissingColumnsError is returned when the header of the source sheet lacks required columns, even after
+// resolving the configured aliases. It lists every missing column with the headers accepted for it.
+type MissingColumnsError struct {
+	SheetName       string
+	MissingColumns  []string
+	AcceptedHeaders map[string][]string // headers accepted for every missing column, the column itself first
+}
+
+// Error lists the missing required columns together with the headers accepted for them.
+func (err *MissingColumnsError) Error() string {
+	missingColumns := []string{}
+	for _, column := range err.MissingColumns {
+		missingColumns = append(missingColumns, fmt.Sprintf("%s (%s)", column, strings.Join(err.AcceptedHeaders[column], " / ")))
+	}
+	return fmt.Sprintf("sheet %q is missing required columns: %s", err.SheetName, strings.Join(missingColumns, ", "))
+}
+
+// GetRequiredColumns returns the configured required source columns, or Nama Anak and Tanggal Lahir Anak
+// when none is configured.
+func (cfg *SasaranImunisasiConfig) GetRequiredColumns() []string {
+	if len(cfg.RequiredColumns) > 0 {
+		return cfg.RequiredColumns
+	}
+	return requiredSourceHeaders
+}
+
+// GetSourceHeaderNames returns the expected source header of every normalized expected header and alias.
+// Aliases of a detail_imunisasi prefix apply to every antigen, e.g. "Tgl Imunisasi" maps "Tgl Imunisasi HB0"
+// to "Tanggal Imunisasi HB0".
+func (cfg *SasaranImunisasiConfig) GetSourceHeaderNames() map[string]string {
+	headerNames := make(map[string]string)
+	addHeader := func(header, name string) {
+		if normalized := NormalizeText(header); normalized != EMPTY_STRING {
+			if _, exists := headerNames[normalized]; !exists {
+				headerNames[normalized] = name
+			}
+		}
+	}
+
+	expectedHeaders := cfg.GetExpectedSourceHeaders()
+	for _, column := range []string{cfg.PosyanduColumn, cfg.Pengingat.PhoneColumn} {
+		if column != EMPTY_STRING {
+			expectedHeaders = append(expectedHeaders, column)
+		}
+	}
+	for _, name := range expectedHeaders {
+		addHeader(name, name)
+	}
+
+	// aliases are added in name order so an alias configured for two columns always maps to the same one
+	aliasNames := make([]string, 0, len(cfg.ColumnAliases))
+	for name := range cfg.ColumnAliases {
+		aliasNames = append(aliasNames, name)
+	}
+	sort.Strings(aliasNames)
+	for _, name := range aliasNames {
+		for _, alias := range cfg.ColumnAliases[name] {
+			addHeader(alias, name)
+		}
+	}
+
+	for _, imunisasi := range [][]string{cfg.ImunisasiBayi, cfg.ImunisasiBaduta} {
+		for _, imun := range imunisasi {
+			for _, detail := range cfg.GetDetailImunisasi(imun) {
+				for _, alias := range cfg.ColumnAliases[detail] {
+					addHeader(alias+SPACE+imun, detail+SPACE+imun)
+				}
+			}
+		}
+	}
+	return headerNames
+}
+
+// ValidateSourceColumns checks the source column map contains every required column.
+// Returns a MissingColumnsError listing the missing ones.
+func (svc *SasaranImunisasiService) ValidateSourceColumns(sheetName string, sourceColumnMap map[string]Column) error {
+	missingErr := &MissingColumnsError{SheetName: sheetName, AcceptedHeaders: make(map[string][]string)}
+	for _, name := range svc.Cfg.GetRequiredColumns() {
+		if _, exists := sourceColumnMap[name]; !exists {
+			missingErr.MissingColumns = append(missingErr.MissingColumns, name)
+			missingErr.AcceptedHeaders[name] = append([]string{name}, svc.Cfg.ColumnAliases[name]...)
+		}
+	}
+
+	if len(missingErr.MissingColumns) > 0 {
+		return missingErr
+	}
+	return nil
+}
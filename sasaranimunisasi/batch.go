@@ -0,0 +1,253 @@
+package sasaranimunisasi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sourceFileExtensions holds the extensions of the source files read from a folder
+var sourceFileExtensions = []string{".xlsx", ".xlsm", ".csv"}
+
+// BatchOptions holds the parameters of a batch generation, the command-line counterparts of the form fields
+// of GenerateFileHandler.
+type BatchOptions struct {
+	SheetName     string // detected from the headers when empty or not found
+	SasaranType   string
+	TanggalAcuan  string // reference date in the format "YYYY-MM-DD", defaults to today
+	PisahPosyandu string // "sheet" or "zip" to split the list per posyandu, empty for a single list
+	Status        string // "label" or "angka" for the status columns, empty for the configured mode
+	Layout        string // "lengkap" or "ringkas" for the sasaran sheets and PDF, empty for the configured layout
+	OutputDir     string
+	Pengingat     bool // also write the reminder messages as a CSV next to every generated file
+	Undangan      bool // also write the invitation slips as a PDF next to every generated file
+	Pdf           bool // also write the sasaran list as a print-ready PDF next to every generated file
+}
+
+// BatchResult holds the outcome of the generation of a single source file.
+type BatchResult struct {
+	InputPath     string
+	OutputPath    string // generated xlsx or ZIP file, empty when the generation failed
+	PengingatPath string // reminder CSV, empty when not requested
+	UndanganPath  string // invitation slips PDF, empty when not requested
+	PdfPath       string // print-ready PDF of the sasaran list, empty when not requested
+	Summary       GenerationSummary
+	Err           error
+}
+
+// NewContext validates the options and returns the context carrying the sasaran type, reference date,
+// per-posyandu split mode, status mode and layout, like GenerateFileHandler does with the form fields.
+func (opts BatchOptions) NewContext(ctx context.Context) (context.Context, error) {
+	sasaranType, err := ValidateSasaranType(opts.SasaranType)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, sasaranTypeKey, sasaranType)
+	if opts.TanggalAcuan != EMPTY_STRING {
+		date, err := time.Parse("2006-01-02", opts.TanggalAcuan)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tanggal acuan %q, expected format YYYY-MM-DD", opts.TanggalAcuan)
+		}
+		ctx = context.WithValue(ctx, tanggalAcuanKey, date)
+	}
+	pisahPosyandu, err := ValidatePisahPosyandu(opts.PisahPosyandu)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, pisahPosyanduKey, pisahPosyandu)
+	statusImunisasi, err := ValidateStatusImunisasi(opts.Status)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, statusImunisasiKey, statusImunisasi)
+	layout, err := ValidateLayout(opts.Layout)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, layoutKey, layout), nil
+}
+
+// ListSourceFiles returns the xlsx and CSV files of the given folder sorted by name, skipping hidden files
+// and the lock files Excel leaves next to an open workbook. A path to a file is returned as is.
+func ListSourceFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	sourceFiles := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~$") {
+			continue
+		}
+		for _, extension := range sourceFileExtensions {
+			if strings.EqualFold(filepath.Ext(name), extension) {
+				sourceFiles = append(sourceFiles, filepath.Join(path, name))
+				break
+			}
+		}
+	}
+	sort.Strings(sourceFiles)
+	return sourceFiles, nil
+}
+
+// ReadSourceFileFromPath opens the source file at the given path, selects its sheet and reads it into a new
+// FileGeneration.
+func (svc *SasaranImunisasiService) ReadSourceFileFromPath(ctx context.Context, inputPath, sheetName string) (*FileGeneration, error) {
+	sourceFile, err := GetXlsxSourceFile(inputPath, sheetName, ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer sourceFile.Reader.Close()
+
+	if err := svc.SelectSourceSheet(sourceFile); err != nil {
+		return nil, err
+	}
+	return svc.ReadSourceFile(*sourceFile)
+}
+
+// GenerateFileFromPath opens the source file at the given path, selects its sheet and generates the new xlsx file.
+func (svc *SasaranImunisasiService) GenerateFileFromPath(ctx context.Context, inputPath, sheetName string) (*XlsxGeneratedFile, error) {
+	fileGeneration, err := svc.ReadSourceFileFromPath(ctx, inputPath, sheetName)
+	if err != nil {
+		return nil, err
+	}
+	return fileGeneration.NewXlsxGeneratedFile()
+}
+
+// RunBatch generates a file for every given source file into the output folder and returns the result of each,
+// in order. A failing source file does not stop the others. The output names are made unique within the output
+// folder, so neither exports generating the same title nor the outputs of an earlier run are overwritten.
+func (svc *SasaranImunisasiService) RunBatch(inputPaths []string, opts BatchOptions) []BatchResult {
+	results := make([]BatchResult, 0, len(inputPaths))
+	ctx, err := opts.NewContext(context.Background())
+	if err == nil {
+		err = os.MkdirAll(opts.OutputDir, 0o755)
+	}
+	if err != nil {
+		for _, inputPath := range inputPaths {
+			results = append(results, BatchResult{InputPath: inputPath, Err: err})
+		}
+		return results
+	}
+
+	usedNames := GetUsedFileNames(opts.OutputDir)
+	for _, inputPath := range inputPaths {
+		result := BatchResult{InputPath: inputPath}
+		err := svc.RunBatchFile(&result, ctx, opts, usedNames)
+		if err != nil {
+			log.Printf("Error generating %s: %v", inputPath, err)
+			result.Err = err
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// RunBatchFile generates the files of a single source file of the batch into the output folder, filling the result.
+func (svc *SasaranImunisasiService) RunBatchFile(result *BatchResult, ctx context.Context, opts BatchOptions, usedNames map[string]bool) error {
+	fileGeneration, err := svc.ReadSourceFileFromPath(ctx, result.InputPath, opts.SheetName)
+	if err != nil {
+		return err
+	}
+	result.Summary = fileGeneration.Summary
+
+	generatedFile, err := fileGeneration.NewXlsxGeneratedFile()
+	if err != nil {
+		return err
+	}
+	if result.OutputPath, err = WriteGeneratedFile(opts.OutputDir, generatedFile, usedNames); err != nil {
+		return err
+	}
+	if opts.Pengingat {
+		if result.PengingatPath, err = WritePengingatFile(result.OutputPath, fileGeneration.PengingatList); err != nil {
+			return err
+		}
+	}
+	if opts.Undangan {
+		if result.UndanganPath, err = WritePdfFile(opts.OutputDir, fileGeneration.NewUndanganPdf(), usedNames); err != nil {
+			return err
+		}
+	}
+	if opts.Pdf {
+		if result.PdfPath, err = WritePdfFile(opts.OutputDir, fileGeneration.NewSasaranPdf(), usedNames); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WritePdfFile writes the generated PDF file into the given folder under a file name not used yet in the
+// batch and returns its path.
+func WritePdfFile(dir string, pdfFile *PdfGeneratedFile, usedNames map[string]bool) (string, error) {
+	pdfPath := filepath.Join(dir, GetUniqueFileName(pdfFile.FileName, usedNames))
+	if err := pdfFile.Pdf.OutputFileAndClose(pdfPath); err != nil {
+		return EMPTY_STRING, fmt.Errorf("failed to write %s: %w", pdfPath, err)
+	}
+	return pdfPath, nil
+}
+
+// WriteGeneratedFile writes the generated Excel file to the given folder, or a ZIP archive when it has one file
+// per posyandu, and returns its path. A name already in usedNames gets a numbered suffix, e.g. "... (2).xlsx".
+func WriteGeneratedFile(dir string, generatedFile *XlsxGeneratedFile, usedNames map[string]bool) (string, error) {
+	fileName := generatedFile.FileName
+	writeFile := func(w io.Writer) error { return generatedFile.ExcelizeFile.Write(w) }
+	if len(generatedFile.PosyanduFiles) > 0 {
+		fileName = generatedFile.GetZipFileName()
+		writeFile = func(w io.Writer) error { return WriteZipFile(w, generatedFile) }
+	}
+	fileName = GetUniqueFileName(fileName, usedNames)
+
+	outputPath := filepath.Join(dir, fileName)
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return EMPTY_STRING, err
+	}
+	if err := writeFile(file); err != nil {
+		file.Close()
+		return EMPTY_STRING, fmt.Errorf("failed to write %s: %w", outputPath, err)
+	}
+	return outputPath, file.Close()
+}
+
+// WritePengingatFile writes the reminder messages as a CSV next to the generated file, named after it, e.g.
+// "Sasaran Imunisasi Bayi 1 Oktober Pengingat.csv", and returns its path.
+func WritePengingatFile(outputPath string, pengingatList []Pengingat) (string, error) {
+	pengingatPath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + SPACE + PENGINGAT_SHEET_NAME + ".csv"
+	file, err := os.Create(pengingatPath)
+	if err != nil {
+		return EMPTY_STRING, err
+	}
+	if err := WritePengingatCsv(file, pengingatList); err != nil {
+		file.Close()
+		return EMPTY_STRING, fmt.Errorf("failed to write %s: %w", pengingatPath, err)
+	}
+	return pengingatPath, file.Close()
+}
+
+// GetUniqueFileName returns the file name, followed by a number before its extension when it is already in
+// usedNames, and marks the returned name as used. Names are compared case-insensitively.
+func GetUniqueFileName(fileName string, usedNames map[string]bool) string {
+	extension := filepath.Ext(fileName)
+	baseName := strings.TrimSuffix(fileName, extension)
+	uniqueName := fileName
+	for i := 2; usedNames[strings.ToLower(uniqueName)]; i++ {
+		uniqueName = fmt.Sprintf("%s (%d)%s", baseName, i, extension)
+	}
+	usedNames[strings.ToLower(uniqueName)] = true
+	return uniqueName
+}
@@ -0,0 +1,73 @@
+package sasaranimunisasi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestRunBatch(t *testing.T) {
+	svc := newTestService(t, loadTestConfig(t))
+	inputDir, outputDir := t.TempDir(), filepath.Join(t.TempDir(), "out")
+
+	source := newSourceXlsx(t, svc.SasaranBayiColumnMap, "Bayi", 5)
+	files := map[string][]byte{
+		"a.xlsx":       source,
+		"b.xlsx":       source,
+		"c.csv":        []byte("Nama\tAlamat\nBudi\tWanasari\n"),
+		"catatan.txt":  []byte("not a source file"),
+		"~$a.xlsx":     []byte("excel lock file"),
+		".hidden.xlsx": source,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(inputDir, name), content, 0o644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	inputPaths, err := ListSourceFiles(inputDir)
+	if err != nil {
+		t.Fatalf("listing source files: %v", err)
+	}
+	if len(inputPaths) != 3 {
+		t.Fatalf("got source files %v, want a.xlsx, b.xlsx and c.csv", inputPaths)
+	}
+
+	results := svc.RunBatch(inputPaths, BatchOptions{SasaranType: BAYI, TanggalAcuan: "2024-10-01", OutputDir: outputDir})
+	wantOutputs := []string{"Sasaran Imunisasi Bayi 1 Oktober.xlsx", "Sasaran Imunisasi Bayi 1 Oktober (2).xlsx", ""}
+	for i, result := range results {
+		if filepath.Base(result.OutputPath) != filepath.Base(wantOutputs[i]) || (result.Err == nil) != (wantOutputs[i] != "") {
+			t.Errorf("%s: got output %q and error %v, want output %q", result.InputPath, result.OutputPath, result.Err, wantOutputs[i])
+			continue
+		}
+		if result.Err != nil {
+			continue
+		}
+
+		generated, err := excelize.OpenFile(result.OutputPath)
+		if err != nil {
+			t.Errorf("opening %s: %v", result.OutputPath, err)
+			continue
+		}
+		generated.Close()
+		if result.Summary.SasaranRows != 5 {
+			t.Errorf("%s: got %d sasaran rows, want 5", result.InputPath, result.Summary.SasaranRows)
+		}
+	}
+
+	// a second run into the same folder keeps the outputs of the first one
+	results = svc.RunBatch(inputPaths[:1], BatchOptions{SasaranType: BAYI, TanggalAcuan: "2024-10-01", OutputDir: outputDir})
+	if got, want := filepath.Base(results[0].OutputPath), "Sasaran Imunisasi Bayi 1 Oktober (3).xlsx"; results[0].Err != nil || got != want {
+		t.Errorf("second run: got output %q and error %v, want output %q", got, results[0].Err, want)
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 3 {
+		t.Errorf("got %d files in the output folder after two runs, want 3", len(entries))
+	}
+
+	results = svc.RunBatch(inputPaths[:1], BatchOptions{SasaranType: "balita", OutputDir: outputDir})
+	if results[0].Err == nil {
+		t.Errorf("got no error for an unknown sasaran type")
+	}
+}
@@ -0,0 +1,167 @@
+package sasaranimunisasi
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// consts for the coverage (cakupan) sheet
+const (
+	CAKUPAN_SHEET_NAME = "Rekap Cakupan"
+	JK_LAKI_LAKI       = "L"
+	JK_PEREMPUAN       = "P"
+	JK_TOTAL           = "Total"
+)
+
+// jenisKelaminCakupan holds the jenis kelamin breakdown of the coverage sheet, in column order
+var jenisKelaminCakupan = []string{JK_LAKI_LAKI, JK_PEREMPUAN, JK_TOTAL}
+
+// CakupanImunisasi holds the coverage counts of an antigen by jenis kelamin, including the total.
+// Sasaran counts the eligible children, Sudah those with an ideal status and Belum those with a non-ideal status.
+type CakupanImunisasi struct {
+	Imunisasi string         `json:"imunisasi"`
+	Sasaran   map[string]int `json:"sasaran"`
+	Sudah     map[string]int `json:"sudah"`
+	Belum     map[string]int `json:"belum"`
+}
+
+// NewCakupanImunisasi initializes the coverage counts of every given antigen.
+func NewCakupanImunisasi(imunisasi []string) []*CakupanImunisasi {
+	cakupanImunisasi := []*CakupanImunisasi{}
+	for _, imun := range imunisasi {
+		cakupanImunisasi = append(cakupanImunisasi, &CakupanImunisasi{
+			Imunisasi: imun,
+			Sasaran:   make(map[string]int),
+			Sudah:     make(map[string]int),
+			Belum:     make(map[string]int),
+		})
+	}
+	return cakupanImunisasi
+}
+
+// GetPersentase returns the coverage percentage for the given jenis kelamin, rounded to two decimals.
+func (cakupan *CakupanImunisasi) GetPersentase(jenisKelamin string) float64 {
+	if cakupan.Sasaran[jenisKelamin] == 0 {
+		return 0
+	}
+	return math.Round(float64(cakupan.Sudah[jenisKelamin])*10000/float64(cakupan.Sasaran[jenisKelamin])) / 100
+}
+
+// GetJenisKelaminCode returns "L" or "P" for the jenis kelamin anak value, or an empty string when it is unknown.
+func GetJenisKelaminCode(jenisKelaminAnak string) string {
+	switch value := strings.ToLower(strings.TrimSpace(jenisKelaminAnak)); {
+	case strings.HasPrefix(value, "l"):
+		return JK_LAKI_LAKI
+	case strings.HasPrefix(value, "p"):
+		return JK_PEREMPUAN
+	}
+	return EMPTY_STRING
+}
+
+// IsStatusIdeal reports whether the detail imunisasi has an ideal status in the source file.
+func (detailImunisasi DetailImunisasi) IsStatusIdeal() bool {
+	for _, status := range detailImunisasi.Status {
+		if status == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// AddCakupan counts the child in the coverage of every antigen the child is eligible for. A child is eligible
+// once the antigen is due according to jadwal imunisasi, antigens without a schedule count every child. The
+// non-ideal antigens, like in the status columns, are counted as Belum and the others as Sudah.
+func (gen *SasaranImunisasiGeneration) AddCakupan(sasaranImunisasi SasaranImunisasi) {
+	jenisKelamin := GetJenisKelaminCode(sasaranImunisasi.JenisKelaminAnak)
+	for _, cakupan := range gen.Cakupan {
+		if status, scheduled := sasaranImunisasi.StatusJadwal[cakupan.Imunisasi]; scheduled && status == JADWAL_BELUM_WAKTUNYA {
+			continue
+		}
+
+		counts := cakupan.Sudah
+		if sasaranImunisasi.IsImunisasiNonIdeal(cakupan.Imunisasi) {
+			counts = cakupan.Belum
+		}
+
+		cakupan.Sasaran[JK_TOTAL]++
+		counts[JK_TOTAL]++
+		if jenisKelamin != EMPTY_STRING {
+			cakupan.Sasaran[jenisKelamin]++
+			counts[jenisKelamin]++
+		}
+	}
+}
+
+// GetCakupanSheetName returns the name of the coverage sheet of the generation. The sasaran type is added
+// when several sasaran types share one file.
+func (gen *SasaranImunisasiGeneration) GetCakupanSheetName(isSingle bool) string {
+	if isSingle {
+		return CAKUPAN_SHEET_NAME
+	}
+	return CAKUPAN_SHEET_NAME + SPACE + gen.GetSheetName()
+}
+
+// CakupanSheet generates the coverage sheet listing the coverage of every antigen by jenis kelamin.
+// It implements NewXlsxGenerator.
+type CakupanSheet struct {
+	Title   string
+	Cakupan []*CakupanImunisasi
+}
+
+// NewCakupanSheet returns the coverage sheet of the generation.
+func (gen *SasaranImunisasiGeneration) NewCakupanSheet() *CakupanSheet {
+	return &CakupanSheet{
+		Title:   GetCakupanTitle(gen.SasaranType, gen.TanggalAcuan),
+		Cakupan: gen.Cakupan,
+	}
+}
+
+// GetCakupanTitle returns the title of the coverage sheet for the given sasaran type and reference date
+func GetCakupanTitle(sasaranType string, tanggalAcuan time.Time) string {
+	return "Rekap Cakupan Imunisasi " + CapitalizeFirstChar(sasaranType) + SPACE + GetDateStr(tanggalAcuan)
+}
+
+// getCakupanHeader returns the header of the coverage sheet, one column per count and jenis kelamin
+func getCakupanHeader() []string {
+	header := []string{"Imunisasi"}
+	for _, count := range []string{"Sasaran", "Sudah", "Belum", "Cakupan (%)"} {
+		for _, jenisKelamin := range jenisKelaminCakupan {
+			header = append(header, count+SPACE+jenisKelamin)
+		}
+	}
+	return header
+}
+
+// SetTitle sets the title of the coverage sheet
+func (sheet *CakupanSheet) SetTitle(newFile NewXlsxFile) {
+	newFile.SetTitleRow(sheet.Title, len(getCakupanHeader()))
+}
+
+// SetHeader sets the header row of the coverage sheet
+func (sheet *CakupanSheet) SetHeader(newFile NewXlsxFile) {
+	newFile.SetRow(newFile.HeaderRowAt, ToInterfaces(getCakupanHeader()), newFile.HeaderStyle)
+}
+
+// SetBody sets the body rows of the coverage sheet
+func (sheet *CakupanSheet) SetBody(newFile NewXlsxFile) {
+	for i, cakupan := range sheet.Cakupan {
+		values := []interface{}{cakupan.Imunisasi}
+		for _, counts := range []map[string]int{cakupan.Sasaran, cakupan.Sudah, cakupan.Belum} {
+			for _, jenisKelamin := range jenisKelaminCakupan {
+				values = append(values, counts[jenisKelamin])
+			}
+		}
+		for _, jenisKelamin := range jenisKelaminCakupan {
+			values = append(values, cakupan.GetPersentase(jenisKelamin))
+		}
+
+		newFile.SetRow(i+newFile.StartBodyRowAt, values, newFile.BodyStyle)
+	}
+}
+
+// SetColumnWidth sets the column width of the coverage sheet
+func (sheet *CakupanSheet) SetColumnWidth(newFile NewXlsxFile) {
+	newFile.StreamWriter.SetColWidth(1, 1, 20)
+	newFile.StreamWriter.SetColWidth(2, len(getCakupanHeader()), 14)
+}
@@ -0,0 +1,86 @@
+package sasaranimunisasi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddCakupan(t *testing.T) {
+	gen := &SasaranImunisasiGeneration{Cakupan: NewCakupanImunisasi([]string{"HB0", "MR 1", IDL_1})}
+	sudah := DetailImunisasi{Status: map[string]int{STATUS: 0}}
+	belum := DetailImunisasi{Status: map[string]int{STATUS: 1}}
+
+	for _, sasaranImunisasi := range []SasaranImunisasi{
+		{
+			JenisKelaminAnak: "Laki-laki",
+			DetailImunisasi:  map[string]DetailImunisasi{"HB0": sudah, "MR 1": belum, IDL_1: sudah},
+			StatusJadwal:     map[string]StatusJadwal{"HB0": JADWAL_SUDAH, "MR 1": JADWAL_JATUH_TEMPO},
+		},
+		{
+			JenisKelaminAnak: "Perempuan",
+			DetailImunisasi:  map[string]DetailImunisasi{"HB0": belum, "MR 1": belum},
+			StatusJadwal:     map[string]StatusJadwal{"HB0": JADWAL_TERLAMBAT, "MR 1": JADWAL_BELUM_WAKTUNYA},
+		},
+		{
+			// counted in the total only, HB0 given with a recorded date despite its non-ideal source status
+			JenisKelaminAnak: "-",
+			DetailImunisasi: map[string]DetailImunisasi{"HB0": {
+				Tanggal: map[string]time.Time{"Tanggal Imunisasi HB0": date(2024, time.January, 10)},
+				Status:  map[string]int{STATUS: 1},
+			}},
+			StatusJadwal: map[string]StatusJadwal{"HB0": JADWAL_SUDAH, "MR 1": JADWAL_BELUM_WAKTUNYA},
+		},
+	} {
+		gen.AddCakupan(sasaranImunisasi)
+	}
+
+	tests := []struct {
+		imunisasi             string
+		jenisKelamin          string
+		sasaran, sudah, belum int
+		persentase            float64
+	}{
+		{"HB0", JK_LAKI_LAKI, 1, 1, 0, 100},
+		{"HB0", JK_PEREMPUAN, 1, 0, 1, 0},
+		{"HB0", JK_TOTAL, 3, 2, 1, 66.67},
+		// not due yet for the second and third child, so they are left out of the denominator
+		{"MR 1", JK_LAKI_LAKI, 1, 0, 1, 0},
+		{"MR 1", JK_PEREMPUAN, 0, 0, 0, 0},
+		{"MR 1", JK_TOTAL, 1, 0, 1, 0},
+		// without a schedule every child is eligible
+		{IDL_1, JK_TOTAL, 3, 1, 2, 33.33},
+	}
+	for _, test := range tests {
+		cakupan := gen.Cakupan[0]
+		for _, c := range gen.Cakupan {
+			if c.Imunisasi == test.imunisasi {
+				cakupan = c
+			}
+		}
+		if cakupan.Sasaran[test.jenisKelamin] != test.sasaran || cakupan.Sudah[test.jenisKelamin] != test.sudah ||
+			cakupan.Belum[test.jenisKelamin] != test.belum {
+			t.Errorf("%s %s: got sasaran %d, sudah %d, belum %d, want %d, %d, %d", test.imunisasi, test.jenisKelamin,
+				cakupan.Sasaran[test.jenisKelamin], cakupan.Sudah[test.jenisKelamin], cakupan.Belum[test.jenisKelamin],
+				test.sasaran, test.sudah, test.belum)
+		}
+		if got := cakupan.GetPersentase(test.jenisKelamin); got != test.persentase {
+			t.Errorf("%s %s: got persentase %v, want %v", test.imunisasi, test.jenisKelamin, got, test.persentase)
+		}
+	}
+}
+
+func TestGetJenisKelaminCode(t *testing.T) {
+	tests := map[string]string{
+		"Laki-laki":  JK_LAKI_LAKI,
+		" L ":        JK_LAKI_LAKI,
+		"PEREMPUAN":  JK_PEREMPUAN,
+		"p":          JK_PEREMPUAN,
+		HYPHEN:       EMPTY_STRING,
+		EMPTY_STRING: EMPTY_STRING,
+	}
+	for jenisKelaminAnak, want := range tests {
+		if got := GetJenisKelaminCode(jenisKelaminAnak); got != want {
+			t.Errorf("GetJenisKelaminCode(%q) = %q, want %q", jenisKelaminAnak, got, want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// consts for the print-ready PDF file of the sasaran list
+const (
+	NOMOR       = "No."
+	PDF_JABATAN = "Koordinator Imunisasi Puskesmas"
+)
+
+// PdfConfig holds the layout and the signature block of the print-ready PDF file of the sasaran list.
+type PdfConfig struct {
+	Layout        string `yaml:"layout"`        // "lengkap" or "ringkas"
+	Tempat        string `yaml:"tempat"`        // place written before the date of the signature, e.g. "Wanasari"
+	Jabatan       string `yaml:"jabatan"`       // position of the signer
+	Penandatangan string `yaml:"penandatangan"` // name of the signer, left blank to be written by hand
+	Nip           string `yaml:"nip"`           // employee number of the signer, optional
+}
+
+// Compile fills the defaults of the PDF file and validates its layout. It is safe to call more than once.
+func (cfg *PdfConfig) Compile() error {
+	if cfg.Layout == EMPTY_STRING {
+		cfg.Layout = LAYOUT_LENGKAP
+	}
+	if cfg.Layout != LAYOUT_LENGKAP && cfg.Layout != LAYOUT_RINGKAS {
+		return fmt.Errorf("invalid pdf layout %q, accepted values: %s, %s", cfg.Layout, LAYOUT_LENGKAP, LAYOUT_RINGKAS)
+	}
+	if cfg.Jabatan == EMPTY_STRING {
+		cfg.Jabatan = PDF_JABATAN
+	}
+	return nil
+}
+
+// GenerateSasaranPdf processes the provided source file like GenerateFile and returns the sasaran list as a
+// print-ready PDF file.
+func (svc *SasaranImunisasiService) GenerateSasaranPdf(sourceFile XlsxSourceFile) (*PdfGeneratedFile, error) {
+	fileGeneration, err := svc.ReadSourceFile(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	return fileGeneration.NewSasaranPdf(), nil
+}
+
+// NewSasaranPdf returns the sasaran list on landscape A4 pages, one table per sasaran sheet of the generated
+// file, each followed by the signature block of the puskesmas coordinator. The layout of the request takes
+// precedence over the configured PDF layout.
+func (fileGeneration *FileGeneration) NewSasaranPdf() *PdfGeneratedFile {
+	cfg := &fileGeneration.Generations[0].Cfg.Pdf
+	layout := GetLayoutFromContext(fileGeneration.Ctx)
+	if layout == EMPTY_STRING {
+		layout = cfg.Layout
+	}
+	title := GetFileName(fileGeneration.SasaranType, fileGeneration.TanggalAcuan)
+	writer := NewPdfWriter("L", title)
+	writer.SetPageFooter(title)
+
+	for _, generation := range fileGeneration.Generations {
+		generations := []*SasaranImunisasiGeneration{generation}
+		if fileGeneration.PisahPosyandu != EMPTY_STRING {
+			generations = generation.SplitByPosyandu()
+		}
+		for _, gen := range generations {
+			writer.Pdf.AddPage()
+			writer.WriteTitle(gen.GetTitle())
+			writer.WriteTable(gen.GetPdfTable(layout))
+			writer.WriteSignature(cfg, gen.TanggalAcuan)
+		}
+	}
+
+	return &PdfGeneratedFile{FileName: title + ".pdf", Pdf: writer.Pdf, Summary: fileGeneration.Summary}
+}
+
+// GetPdfTable returns the table of the generated sheet in the given layout, numbered and repeating the number
+// and the name of the child on every column group.
+func (gen *SasaranImunisasiGeneration) GetPdfTable(layout string) PdfTable {
+	header, getRow := gen.GetLengkapHeader(), gen.GetLengkapRow
+	if layout == LAYOUT_RINGKAS {
+		header, getRow = gen.GetRingkasHeader(), gen.GetRingkasRow
+	}
+
+	table := PdfTable{Header: append([]string{NOMOR}, header...), RepeatColumns: 1}
+	if column, exists := gen.SasaranColumnMap[NAMA_ANAK]; exists {
+		table.RepeatColumns += GetColumnIndex(column.Label) + 1
+	}
+	if layout != LAYOUT_RINGKAS {
+		table.ColumnUnits = gen.GetPdfColumnUnits()
+	}
+
+	for i, sasaranImunisasi := range gen.SasaranImunisasiList {
+		row := []string{strconv.Itoa(i + 1)}
+		for _, value := range getRow(sasaranImunisasi) {
+			row = append(row, FormatValue(value))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// GetPdfColumnUnits returns the detail columns of every antigen of the PDF table, kept in the same column group.
+func (gen *SasaranImunisasiGeneration) GetPdfColumnUnits() [][]int {
+	units := [][]int{}
+	for _, imun := range gen.Imunisasi {
+		unit := []int{}
+		for _, name := range gen.Cfg.GetDetailColumnNames(imun) {
+			if column, exists := gen.SasaranColumnMap[name]; exists {
+				// the number column comes first in the PDF table
+				unit = append(unit, GetColumnIndex(column.Label)+1)
+			}
+		}
+		units = append(units, unit)
+	}
+	return units
+}
+
+// WriteSignature writes the signature block of the puskesmas coordinator under the table, on the right of the
+// page, starting a new page when it does not fit.
+func (writer *PdfWriter) WriteSignature(cfg *PdfConfig, tanggal time.Time) {
+	const width, height = 80.0, 40.0
+	pdf := writer.Pdf
+	pageWidth, pageHeight := pdf.GetPageSize()
+	_, bottom := pdf.GetAutoPageBreak()
+	if pdf.GetY()+height > pageHeight-bottom {
+		pdf.AddPage()
+	}
+
+	tanggalStr := fmt.Sprintf("%s %d", GetDateStr(tanggal), tanggal.Year())
+	if cfg.Tempat != EMPTY_STRING {
+		tanggalStr = cfg.Tempat + ", " + tanggalStr
+	}
+	// the name is underlined in bold, or left as a dotted line to be written by hand
+	penandatangan, style := cfg.Penandatangan, "BU"
+	if penandatangan == EMPTY_STRING {
+		penandatangan, style = UNDANGAN_TANGGAL_KOSONG, EMPTY_STRING
+	}
+
+	x := pageWidth - PDF_MARGIN - width
+	pdf.SetXY(x, pdf.GetY()+6)
+	pdf.SetFont(PDF_FONT, EMPTY_STRING, 10)
+	for _, line := range []string{tanggalStr, "Mengetahui,", cfg.Jabatan} {
+		pdf.CellFormat(width, PDF_LINE_HEIGHT, writer.Text(line), EMPTY_STRING, 2, "C", false, 0, EMPTY_STRING)
+	}
+	pdf.SetXY(x, pdf.GetY()+15)
+	pdf.SetFont(PDF_FONT, style, 10)
+	pdf.CellFormat(width, PDF_LINE_HEIGHT, writer.Text(penandatangan), EMPTY_STRING, 2, "C", false, 0, EMPTY_STRING)
+	if cfg.Nip != EMPTY_STRING {
+		pdf.SetFont(PDF_FONT, EMPTY_STRING, 10)
+		pdf.CellFormat(width, PDF_LINE_HEIGHT, writer.Text("NIP. "+cfg.Nip), EMPTY_STRING, 2, "C", false, 0, EMPTY_STRING)
+	}
+}
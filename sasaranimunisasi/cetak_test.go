@@ -0,0 +1,83 @@
+package sasaranimunisasi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestGetColumnGroups(t *testing.T) {
+	widths := []float64{10, 30, 20, 20, 20, 20, 20, 20}
+	table := PdfTable{RepeatColumns: 2, ColumnUnits: [][]int{{3, 4, 5}, {6, 7}}}
+	want := [][]int{{0, 1, 2}, {0, 1, 3, 4, 5}, {0, 1, 6, 7}}
+	if got := GetColumnGroups(widths, table, 100); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got column groups %v, want %v", got, want)
+	}
+	if got := GetColumnGroups(widths, table, 1000); len(got) != 1 || len(got[0]) != len(widths) {
+		t.Errorf("got column groups %v, want a single group", got)
+	}
+}
+
+func TestGenerateSasaranPdf(t *testing.T) {
+	cfg := loadTestConfig(t)
+	svc := newTestService(t, cfg)
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC) }
+
+	file := excelize.NewFile()
+	defer file.Close()
+	rows := [][]interface{}{
+		{NAMA_ANAK, TANGGAL_LAHIR_ANAK, NAMA_ORANG_TUA, "Tanggal Imunisasi HB0", "Status Imunisasi HB0", "Status Imunisasi BCG 1"},
+		{"Ahmad", "2024-09-20", "Ibu Ahmad", "2024-09-20", "0", "1"},
+		{"Siti", "2024-09-01", "Ibu Siti", nil, "1", "1"},
+	}
+	for i, values := range rows {
+		file.SetSheetRow(SHEET_NAME, fmt.Sprintf("A%d", i+1), &values)
+	}
+	fileGeneration, err := svc.ReadSourceFile(XlsxSourceFile{
+		Ctx:       context.WithValue(context.Background(), sasaranTypeKey, BAYI),
+		SheetName: SHEET_NAME,
+		Reader:    NewXlsxReader(file),
+	})
+	if err != nil {
+		t.Fatalf("reading source file: %v", err)
+	}
+	gen := fileGeneration.Generations[0]
+
+	ringkas := gen.GetPdfTable(LAYOUT_RINGKAS)
+	if last := ringkas.Header[len(ringkas.Header)-1]; last != IMUNISASI_BELUM {
+		t.Errorf("got last ringkas column %q, want %q", last, IMUNISASI_BELUM)
+	}
+	imunisasiBelum := map[string]string{}
+	for _, row := range ringkas.Rows {
+		imunisasiBelum[row[1]] = row[len(row)-1]
+	}
+	if got := imunisasiBelum["Ahmad"]; !strings.HasPrefix(got, "BCG 1, POLIO 1") {
+		t.Errorf("got imunisasi belum %q of Ahmad, want starting with BCG 1, POLIO 1", got)
+	}
+	if got := imunisasiBelum["Siti"]; !strings.HasPrefix(got, "HB0, BCG 1") {
+		t.Errorf("got imunisasi belum %q of Siti, want starting with HB0, BCG 1", got)
+	}
+
+	lengkap := gen.GetPdfTable(LAYOUT_LENGKAP)
+	if len(lengkap.Header) != gen.GetColumnCount()+1 || lengkap.Header[0] != NOMOR || lengkap.RepeatColumns != 2 {
+		t.Errorf("got lengkap header %v repeating %d columns", lengkap.Header, lengkap.RepeatColumns)
+	}
+
+	pdfFile := fileGeneration.NewSasaranPdf()
+	var buf bytes.Buffer
+	if err := pdfFile.Pdf.Output(&buf); err != nil {
+		t.Fatalf("writing PDF: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF")) {
+		t.Errorf("got output starting with %q, want a PDF", buf.Bytes()[:min(8, buf.Len())])
+	}
+	// every antigen does not fit a landscape page, so the columns are split into several groups
+	if pages := pdfFile.Pdf.PageCount(); pages < 2 {
+		t.Errorf("got %d pages, want the column groups on several pages", pages)
+	}
+}
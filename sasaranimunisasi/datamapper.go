@@ -1,10 +1,8 @@
 package sasaranimunisasi
 
 import (
-	"context"
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -13,33 +11,37 @@ import (
 type DataRowPopulator struct {
 	SasaranColumnMap map[string]Column
 	SourceColumnMap  map[string]Column
-	RowIndex         int
-	SourceFile       XlsxSourceFile
+	Row              SourceRow
+	TanggalAcuan     time.Time // reference date used to calculate usia anak
 }
 
 // GetSasaranColumnMap returns sasaran column map and last column label based on sasaran type
-func (svc *SasaranImunisasiService) GetSasaranColumnMap(ctx context.Context) (map[string]Column, string) {
-	sasaranType := GetSasaranTypeFromContext(ctx)
+func (svc *SasaranImunisasiService) GetSasaranColumnMap(sasaranType string) (map[string]Column, string) {
 	if sasaranType == BAYI {
 		return svc.SasaranBayiColumnMap, svc.SasaranBayiColumnMap[STATUS_IDL_1].Label
 	}
 	return svc.SasaranBadutaColumnMap, svc.SasaranBadutaColumnMap[STATUS_IMUNISASI_PCV_3].Label
 }
 
-// GetSourceColumnMap returns source column map which includes name and label (e.g.: name "ID" and label "A")
-func (svc *SasaranImunisasiService) GetSourceColumnMap(sourceFile XlsxSourceFile) map[string]Column {
+// GetSourceColumnMap returns source column map which includes name, label and index (e.g.: name "ID", label "A"
+// and index 0), read from every non-empty cell of the header row so a blank header column does not hide the
+// columns after it. Headers matching an expected column or one of its aliases, ignoring case and whitespace,
+// are mapped to the expected column name; the first header mapped to a name wins.
+func (svc *SasaranImunisasiService) GetSourceColumnMap(header []string) map[string]Column {
 	sourceColumnMap := make(map[string]Column)
-
-	// retrieves source label
-	sourceCellIndex := 1
-	for {
-		sourceLabel := GetXlsxColumnLabel(sourceCellIndex)
-		sourceCell := GetCellValue(sourceFile, sourceLabel+strconv.Itoa(1))
-		if sourceCell == HYPHEN {
-			break
+	for i, sourceCell := range header {
+		name := strings.TrimSpace(sourceCell)
+		if name == EMPTY_STRING {
+			continue
+		}
+		if expectedName, exists := svc.SourceHeaderNames[NormalizeText(name)]; exists {
+			name = expectedName
+		}
+		if column, exists := sourceColumnMap[name]; exists {
+			log.Printf("Ignoring source column %s %q, %s is already read from column %s", GetXlsxColumnLabel(i+1), sourceCell, name, column.Label)
+			continue
 		}
-		sourceColumnMap[sourceCell] = Column{Label: sourceLabel}
-		sourceCellIndex++
+		sourceColumnMap[name] = Column{Label: GetXlsxColumnLabel(i + 1), Index: i}
 	}
 
 	return sourceColumnMap
@@ -47,29 +49,20 @@ func (svc *SasaranImunisasiService) GetSourceColumnMap(sourceFile XlsxSourceFile
 
 // PopulateRowsData populates the SasaranImunisasi struct with data from the specified row in the source file.
 // It takes a DataRowPopulator which contains information about the row being processed, including
-// mappings of column names and the source file itself. The method returns a boolean indicating
-// whether the row data is valid and a pointer to the populated SasaranImunisasi struct.
-func (svc *SasaranImunisasiService) PopulateRowsData(populator *DataRowPopulator) (bool, SasaranImunisasi) {
-	isRowValid := true
-	sasaranImunisasi := SasaranImunisasi{}
-	strRowIndex := strconv.Itoa(populator.RowIndex)
+// mappings of column names and the source file itself. The method returns the populated SasaranImunisasi struct.
+func (svc *SasaranImunisasiService) PopulateRowsData(populator *DataRowPopulator) SasaranImunisasi {
+	sasaranImunisasi := SasaranImunisasi{SourceRow: populator.Row.Index}
 	for sasaranColumnName := range populator.SasaranColumnMap {
-		if sasaranColumnName == USIA_ANAK {
+		if IsComputedColumn(sasaranColumnName) {
 			continue
 		}
 
-		cell := populator.SourceColumnMap[sasaranColumnName].Label + strRowIndex
-		sourceFile := populator.SourceFile
-
-		if IsSourceInvalid(sasaranColumnName, cell, sourceFile) {
-			isRowValid = false
-			break
-		}
-
-		sasaranImunisasi.PopulateSasaranImunisasi(GetCellValue(sourceFile, cell), sasaranColumnName, svc.Cfg)
+		cellValue := populator.Row.GetValue(populator.SourceColumnMap[sasaranColumnName])
+		sasaranImunisasi.PopulateSasaranImunisasi(cellValue, sasaranColumnName, svc.Cfg)
 	}
+	sasaranImunisasi.UsiaAnak = sasaranImunisasi.CalculateUsiaAnak(populator.TanggalAcuan)
 
-	return isRowValid, sasaranImunisasi
+	return sasaranImunisasi
 }
 
 // PopulateSasaranImunisasi populates sasaran imunisasi data for each column name with given cell value
@@ -78,7 +71,8 @@ func (sasaranImunisasi *SasaranImunisasi) PopulateSasaranImunisasi(cellValue, sa
 	case NAMA_ANAK:
 		sasaranImunisasi.NamaAnak = cellValue
 	case TANGGAL_LAHIR_ANAK:
-		sasaranImunisasi.TanggalLahirAnak = cellValue
+		// unreadable birth dates are rejected before the row is populated
+		sasaranImunisasi.TanggalLahirAnak, _ = cfg.ParseTanggal(cellValue)
 	case JENIS_KELAMIN_ANAK:
 		sasaranImunisasi.JenisKelaminAnak = cellValue
 	case NAMA_ORANG_TUA:
@@ -89,17 +83,21 @@ func (sasaranImunisasi *SasaranImunisasi) PopulateSasaranImunisasi(cellValue, sa
 		detailImunisasi := sasaranImunisasi.GetDetailImunisasi(sasaranColumnName, cfg)
 		switch {
 		case strings.Contains(sasaranColumnName, TANGGAL):
-			detailImunisasi.Tanggal[sasaranColumnName] = cellValue
+			if cellValue == HYPHEN {
+				return
+			}
+			tanggal, err := cfg.ParseTanggal(cellValue)
+			if err != nil {
+				log.Printf("Ignoring %s of %s: %v", sasaranColumnName, sasaranImunisasi.NamaAnak, err)
+				return
+			}
+			detailImunisasi.Tanggal[sasaranColumnName] = tanggal
 		case strings.Contains(sasaranColumnName, POS):
 			detailImunisasi.Pos[sasaranColumnName] = cellValue
 		case strings.Contains(sasaranColumnName, STATUS):
 			detailImunisasi.Status[sasaranColumnName] = GetStatusImunisasi(cellValue)
 		}
 	}
-
-	if sasaranColumnName == TANGGAL_LAHIR_ANAK {
-		sasaranImunisasi.UsiaAnak = sasaranImunisasi.CalculateUsiaAnak()
-	}
 }
 
 // GetDetailImunisasi returns detail imunisasi of given sasaran imunisasi based on imunisasi type
@@ -111,7 +109,7 @@ func (s *SasaranImunisasi) GetDetailImunisasi(sasaranColumnName string, cfg *Sas
 
 	if _, exists := s.DetailImunisasi[imunisasiType]; !exists {
 		s.DetailImunisasi[imunisasiType] = DetailImunisasi{
-			Tanggal: make(map[string]string),
+			Tanggal: make(map[string]time.Time),
 			Pos:     make(map[string]string),
 			Status:  make(map[string]int),
 		}
@@ -146,47 +144,25 @@ func GetStatusImunisasi(status string) int {
 	return 1
 }
 
-// CountNonIdealImmunizations counts all non ideal imunisasi
-func (s *SasaranImunisasi) CountNonIdealImmunizations() int {
+// CountNonIdealImmunizations counts the non ideal imunisasi among the given antigens, see IsImunisasiNonIdeal
+func (s *SasaranImunisasi) CountNonIdealImmunizations(imunisasi []string) int {
 	count := 0
-	for _, detailImunisasi := range s.DetailImunisasi {
-		for _, status := range detailImunisasi.Status {
-			if status > 0 {
-				count++
-			}
+	for _, imun := range imunisasi {
+		if s.IsImunisasiNonIdeal(imun) {
+			count++
 		}
 	}
 	return count
 }
 
-// IsSourceInvalid checks whether the immunization information from source file is valid
-// based on the given column and row index. It returns true if invalid, otherwise false.
-func IsSourceInvalid(sasaranColumnName, cell string, sourceFile XlsxSourceFile) bool {
-	if strings.Contains(sasaranColumnName, POS) {
-		cellValue := strings.ToLower(GetCellValue(sourceFile, cell))
-		switch {
-		case strings.Contains(cellValue, "cibuntu"):
-			return true
-		case strings.Contains(cellValue, "wanasari"),
-			strings.Contains(cellValue, "dalam gedung"),
-			strings.Contains(cellValue, "oleh sistem"),
-			cellValue == HYPHEN:
-			return false
-		}
-	}
-	return false
-}
-
-// CalculateUsiaAnak calculates the age of a child based on their birth date in the format "YYYY-MM-DD".
-// It returns a string indicating the age in months and days.
-func (sasaranImunisasi *SasaranImunisasi) CalculateUsiaAnak() string {
-	birthDate, err := time.Parse("2006-01-02", sasaranImunisasi.TanggalLahirAnak)
-	if err != nil {
-		log.Printf("Failed to parse tanggal lahir anak: %v", err)
+// CalculateUsiaAnak calculates the age of a child at the given reference date based on their birth date.
+// It returns a string indicating the age in months and days, or "-" when the birth date is unknown.
+func (sasaranImunisasi *SasaranImunisasi) CalculateUsiaAnak(currentDate time.Time) string {
+	birthDate := sasaranImunisasi.TanggalLahirAnak
+	if birthDate.IsZero() {
 		return "-"
 	}
 
-	currentDate := time.Now()
 	months := currentDate.Year()*12 + int(currentDate.Month()) - (birthDate.Year()*12 + int(birthDate.Month()))
 	days := currentDate.Day() - birthDate.Day()
 
@@ -0,0 +1,70 @@
+package sasaranimunisasi
+
+// consts for the JSON document
+const (
+	DOCUMENT_VERSION = 2 // incremented on every breaking change of SasaranImunisasiDocument
+)
+
+// SasaranImunisasiDocument is the JSON representation of a file generation, returned instead of the xlsx
+// file when the client asks for JSON. It holds the same data as the generated file.
+type SasaranImunisasiDocument struct {
+	Version      int                   `json:"version"`
+	Title        string                `json:"title"`
+	SasaranType  string                `json:"sasaranType"`
+	TanggalAcuan string                `json:"tanggalAcuan"` // reference date in the format "YYYY-MM-DD"
+	Sasaran      []SasaranDocumentList `json:"sasaran"`
+	Summary      GenerationSummary     `json:"summary"`
+	RejectedRows []RejectedRow         `json:"rejectedRows"`
+	Duplicates   []DuplicateRecord     `json:"duplicates"` // source rows merged into an earlier record of the same child
+}
+
+// SasaranDocumentList holds the filtered sasaran imunisasi list and the coverage of a single sasaran type.
+type SasaranDocumentList struct {
+	SasaranType      string              `json:"sasaranType"`
+	Title            string              `json:"title"`
+	Imunisasi        []string            `json:"imunisasi"`
+	Jumlah           int                 `json:"jumlah"`
+	SasaranImunisasi []SasaranImunisasi  `json:"sasaranImunisasi"`
+	Cakupan          []*CakupanImunisasi `json:"cakupan"`
+}
+
+// GenerateDocument processes the provided source file like GenerateFile and returns the result as a JSON document.
+func (svc *SasaranImunisasiService) GenerateDocument(sourceFile XlsxSourceFile) (*SasaranImunisasiDocument, error) {
+	fileGeneration, err := svc.ReadSourceFile(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	return fileGeneration.NewDocument(), nil
+}
+
+// NewDocument returns the JSON document of the file generation.
+func (fileGeneration *FileGeneration) NewDocument() *SasaranImunisasiDocument {
+	document := &SasaranImunisasiDocument{
+		Version:      DOCUMENT_VERSION,
+		Title:        GetFileName(fileGeneration.SasaranType, fileGeneration.TanggalAcuan),
+		SasaranType:  fileGeneration.SasaranType,
+		TanggalAcuan: fileGeneration.TanggalAcuan.Format("2006-01-02"),
+		Sasaran:      []SasaranDocumentList{},
+		Summary:      fileGeneration.Summary,
+		RejectedRows: fileGeneration.RejectedRows,
+		Duplicates:   fileGeneration.Duplicates,
+	}
+	if document.RejectedRows == nil {
+		document.RejectedRows = []RejectedRow{}
+	}
+	if document.Duplicates == nil {
+		document.Duplicates = []DuplicateRecord{}
+	}
+
+	for _, generation := range fileGeneration.Generations {
+		document.Sasaran = append(document.Sasaran, SasaranDocumentList{
+			SasaranType:      generation.SasaranType,
+			Title:            generation.GetTitle(),
+			Imunisasi:        generation.Imunisasi,
+			Jumlah:           len(generation.SasaranImunisasiList),
+			SasaranImunisasi: generation.SasaranImunisasiList,
+			Cakupan:          generation.Cakupan,
+		})
+	}
+	return document
+}
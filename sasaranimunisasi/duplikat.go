@@ -0,0 +1,218 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// consts for the duplicate (duplikat) sheet
+const (
+	DUPLIKAT_SHEET_NAME = "Duplikat"
+)
+
+// DuplicateRecord represents a source row merged into the record of the same child read from an earlier row.
+type DuplicateRecord struct {
+	RowNumber        int       `json:"rowNumber"`
+	NamaAnak         string    `json:"namaAnak"`
+	TanggalLahirAnak time.Time `json:"tanggalLahirAnak"`
+	NamaOrangTua     string    `json:"namaOrangTua"`
+	Posyandu         string    `json:"posyandu"`
+	SasaranType      string    `json:"sasaranType"`
+	MergedIntoRow    int       `json:"mergedIntoRow"`
+	MergedIntoNama   string    `json:"mergedIntoNama"`
+	Similarity       float64   `json:"similarity"`     // lowest of the child and parent name similarities, 1 for equal names
+	AddedImunisasi   []string  `json:"addedImunisasi"` // antigens given according to the duplicate only
+}
+
+// GetDuplicateNameSimilarity returns the configured minimum name similarity of duplicates, between 0 and 1.
+// Names must be equal once normalized when it is not configured.
+func (cfg *SasaranImunisasiConfig) GetDuplicateNameSimilarity() float64 {
+	if cfg.DuplicateNameSimilarity <= 0 || cfg.DuplicateNameSimilarity > 1 {
+		return 1
+	}
+	return cfg.DuplicateNameSimilarity
+}
+
+// GetNameSimilarity returns the similarity of two names between 0 and 1, computed from the Levenshtein
+// distance of the normalized names. Normalized names that are equal have a similarity of 1.
+func GetNameSimilarity(a, b string) float64 {
+	runesA, runesB := []rune(NormalizeText(a)), []rune(NormalizeText(b))
+	maxLen := max(len(runesA), len(runesB))
+	if maxLen == 0 {
+		return 1
+	}
+
+	// keep only the previous row of the distance matrix
+	previous := make([]int, len(runesB)+1)
+	current := make([]int, len(runesB)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(runesA); i++ {
+		current[0] = i
+		for j := 1; j <= len(runesB); j++ {
+			cost := 1
+			if runesA[i-1] == runesB[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return 1 - float64(previous[len(runesB)])/float64(maxLen)
+}
+
+// GetDuplicateSimilarity returns the similarity of two records of the same birth date, the lowest of the
+// child and parent name similarities.
+func (sasaranImunisasi *SasaranImunisasi) GetDuplicateSimilarity(other SasaranImunisasi) float64 {
+	return math.Min(GetNameSimilarity(sasaranImunisasi.NamaAnak, other.NamaAnak),
+		GetNameSimilarity(sasaranImunisasi.NamaOrangTua, other.NamaOrangTua))
+}
+
+// getRank ranks the detail imunisasi to keep the best one when merging duplicates: 2 for an ideal status,
+// 1 for a recorded date with a non-ideal status and 0 when the immunization has not been received.
+func (detailImunisasi DetailImunisasi) getRank() int {
+	switch {
+	case detailImunisasi.IsStatusIdeal():
+		return 2
+	case detailImunisasi.IsGiven():
+		return 1
+	}
+	return 0
+}
+
+// MergeDetailImunisasi merges the detail imunisasi of a duplicate, keeping the best status per antigen.
+// It returns the antigens, in the given order, received according to the duplicate only.
+func (sasaranImunisasi *SasaranImunisasi) MergeDetailImunisasi(duplicate SasaranImunisasi, imunisasi []string) []string {
+	addedImunisasi := []string{}
+	for _, imun := range imunisasi {
+		duplicateDetail, exists := duplicate.DetailImunisasi[imun]
+		if !exists {
+			continue
+		}
+
+		detail, exists := sasaranImunisasi.DetailImunisasi[imun]
+		if exists && detail.getRank() >= duplicateDetail.getRank() {
+			continue
+		}
+		if !exists || detail.getRank() == 0 {
+			addedImunisasi = append(addedImunisasi, imun)
+		}
+		if sasaranImunisasi.DetailImunisasi == nil {
+			sasaranImunisasi.DetailImunisasi = make(map[string]DetailImunisasi)
+		}
+		sasaranImunisasi.DetailImunisasi[imun] = duplicateDetail
+	}
+	return addedImunisasi
+}
+
+// MergeDuplicates merges the records of the same child in the generation list: records with the same birth date
+// whose child and parent names are at least as similar as configured. Each duplicate is merged into the first
+// record of the child, recorded as a DuplicateRecord and rejected so every source row stays accounted for.
+func (fileGeneration *FileGeneration) MergeDuplicates(gen *SasaranImunisasiGeneration) {
+	threshold := gen.Cfg.GetDuplicateNameSimilarity()
+	byTanggalLahir := make(map[time.Time][]int) // index in the merged list of every record by birth date
+	merged := []SasaranImunisasi{}
+	for _, sasaranImunisasi := range gen.SasaranImunisasiList {
+		bestIndex, bestSimilarity := -1, 0.0
+		for _, index := range byTanggalLahir[sasaranImunisasi.TanggalLahirAnak] {
+			if similarity := merged[index].GetDuplicateSimilarity(sasaranImunisasi); similarity >= threshold && similarity > bestSimilarity {
+				bestIndex, bestSimilarity = index, similarity
+			}
+		}
+		if bestIndex < 0 {
+			byTanggalLahir[sasaranImunisasi.TanggalLahirAnak] = append(byTanggalLahir[sasaranImunisasi.TanggalLahirAnak], len(merged))
+			merged = append(merged, sasaranImunisasi)
+			continue
+		}
+
+		record := &merged[bestIndex]
+		if record.NomorHp == EMPTY_STRING {
+			record.NomorHp = sasaranImunisasi.NomorHp
+		}
+		addedImunisasi := record.MergeDetailImunisasi(sasaranImunisasi, gen.Imunisasi)
+		if len(addedImunisasi) > 0 && gen.Cfg.PosyanduColumn == EMPTY_STRING {
+			if posyandu := strings.TrimSpace(record.GetPosImunisasiTerakhir(gen.Imunisasi)); posyandu != HYPHEN && posyandu != EMPTY_STRING {
+				record.Posyandu = posyandu
+			}
+		}
+
+		fileGeneration.Duplicates = append(fileGeneration.Duplicates, DuplicateRecord{
+			RowNumber:        sasaranImunisasi.SourceRow,
+			NamaAnak:         sasaranImunisasi.NamaAnak,
+			TanggalLahirAnak: sasaranImunisasi.TanggalLahirAnak,
+			NamaOrangTua:     sasaranImunisasi.NamaOrangTua,
+			Posyandu:         sasaranImunisasi.Posyandu,
+			SasaranType:      gen.SasaranType,
+			MergedIntoRow:    record.SourceRow,
+			MergedIntoNama:   record.NamaAnak,
+			Similarity:       math.Round(bestSimilarity*100) / 100,
+			AddedImunisasi:   addedImunisasi,
+		})
+		fileGeneration.Reject(RejectedRow{
+			RowNumber:   sasaranImunisasi.SourceRow,
+			NamaAnak:    sasaranImunisasi.NamaAnak,
+			SasaranType: gen.SasaranType,
+			Reason:      REASON_DUPLICATE,
+			Detail:      "digabung ke baris " + fmt.Sprint(record.SourceRow),
+		})
+	}
+	gen.SasaranImunisasiList = merged
+}
+
+// DuplicateSheet generates the sheet listing the duplicates merged into an earlier record of the same child,
+// for manual review. It implements NewXlsxGenerator.
+type DuplicateSheet struct {
+	Title      string
+	Duplicates []DuplicateRecord
+}
+
+// duplicateHeader holds the header of the duplicate sheet
+var duplicateHeader = []string{"Baris", "Nama Anak", "Tanggal Lahir Anak", "Nama Orang Tua", "Posyandu", "Sasaran",
+	"Digabung ke Baris", "Nama Anak Digabung", "Kemiripan Nama", "Imunisasi Ditambahkan"}
+
+// GetDuplicateTitle returns the title of the duplicate sheet for the given sasaran type and reference date
+func GetDuplicateTitle(sasaranType string, tanggalAcuan time.Time) string {
+	return fmt.Sprintf("%s %s %s", DUPLIKAT_SHEET_NAME, CapitalizeFirstChar(sasaranType), GetDateStr(tanggalAcuan))
+}
+
+// SetTitle sets the title of the duplicate sheet
+func (sheet *DuplicateSheet) SetTitle(newFile NewXlsxFile) {
+	newFile.SetTitleRow(sheet.Title, len(duplicateHeader))
+}
+
+// SetHeader sets the header row of the duplicate sheet
+func (sheet *DuplicateSheet) SetHeader(newFile NewXlsxFile) {
+	newFile.SetRow(newFile.HeaderRowAt, ToInterfaces(duplicateHeader), newFile.HeaderStyle)
+}
+
+// SetBody sets the body rows of the duplicate sheet
+func (sheet *DuplicateSheet) SetBody(newFile NewXlsxFile) {
+	for i, duplicate := range sheet.Duplicates {
+		addedImunisasi := HYPHEN
+		if len(duplicate.AddedImunisasi) > 0 {
+			addedImunisasi = strings.Join(duplicate.AddedImunisasi, ", ")
+		}
+		values := []interface{}{
+			duplicate.RowNumber,
+			duplicate.NamaAnak,
+			duplicate.TanggalLahirAnak,
+			duplicate.NamaOrangTua,
+			duplicate.Posyandu,
+			CapitalizeFirstChar(duplicate.SasaranType),
+			duplicate.MergedIntoRow,
+			duplicate.MergedIntoNama,
+			duplicate.Similarity,
+			addedImunisasi,
+		}
+		newFile.SetRow(i+newFile.StartBodyRowAt, values, newFile.BodyStyle)
+	}
+}
+
+// SetColumnWidth sets the column width of the duplicate sheet
+func (sheet *DuplicateSheet) SetColumnWidth(newFile NewXlsxFile) {
+	newFile.StreamWriter.SetColWidth(1, 1, 10)
+	newFile.StreamWriter.SetColWidth(2, len(duplicateHeader), 24)
+}
@@ -1,46 +1,186 @@
 package sasaranimunisasi
 
 import (
-	"strconv"
+	"context"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
 )
 
-// SasaranImunisasiService manages sasaran imunisasi data and column mapping for bayi and baduta
+// SasaranImunisasiService manages column mapping for bayi and baduta. It is shared by every
+// request, so it only holds read-only configuration; per-request data lives in SasaranImunisasiGeneration.
 type SasaranImunisasiService struct {
 	Cfg                    *SasaranImunisasiConfig
-	SourceFileColumnMap    map[string]Column
 	SasaranBayiColumnMap   map[string]Column // represents xlsx column map for the generated file
 	SasaranBadutaColumnMap map[string]Column // represents xlsx column map for the generated file
-	SasaranImunisasiList   []SasaranImunisasi
+	Clock                  func() time.Time  // returns the reference date when the request does not give one
+	SourceHeaderNames      map[string]string // expected source header by normalized header or alias
+}
+
+// FileGeneration holds the state of a single upload. It reads the source file once, fills one
+// SasaranImunisasiGeneration per generated sheet and collects the rejected rows and summary shared by them.
+type FileGeneration struct {
+	Ctx             context.Context
+	SasaranType     string            // requested sasaran type, which may generate several sheets
+	TanggalAcuan    time.Time         // reference date for usia anak, jadwal imunisasi and the title
+	SourceColumnMap map[string]Column // represents xlsx column map of the source file
+	PisahPosyandu   string            // per-posyandu split mode, empty when the list is not split
+	Generations     []*SasaranImunisasiGeneration
+	RejectedRows    []RejectedRow
+	Duplicates      []DuplicateRecord // source rows merged into an earlier record of the same child
+	PengingatList   []Pengingat       // reminder message of every child, empty when reminders are not configured
+	Summary         GenerationSummary
+}
+
+// SasaranImunisasiGeneration holds the state of a single generated sasaran sheet,
+// including the sasaran type, the column maps and the filtered sasaran imunisasi list.
+// It implements NewXlsxGenerator for the generated sheet.
+type SasaranImunisasiGeneration struct {
+	Cfg                  *SasaranImunisasiConfig
+	SasaranType          string
+	TanggalAcuan         time.Time         // reference date for usia anak, jadwal imunisasi and the title
+	Posyandu             string            // posyandu of the children when the list is split per posyandu
+	StatusImunisasi      string            // "label" or "angka", the mode of the status columns
+	Layout               string            // "lengkap" or "ringkas", the layout of the generated sheet
+	SasaranColumnMap     map[string]Column // represents xlsx column map for the generated file
+	LastColumnLabel      string
+	Imunisasi            []string          // antigens shown in the generated file
+	JadwalImunisasi      []JadwalImunisasi // schedule of the antigens shown in the generated file
+	SasaranImunisasiList []SasaranImunisasi
+	Cakupan              []*CakupanImunisasi // coverage of every antigen, counted over every child of the sasaran type
+}
+
+// GenerationSummary holds the row counts of a file generation, including rows dropped by the wilayah rules.
+type GenerationSummary struct {
+	SheetName         string         `json:"sheetName"` // source sheet read, which may have been detected
+	SourceRows        int            `json:"sourceRows"`
+	SasaranRows       int            `json:"sasaranRows"`
+	SasaranRowsByType map[string]int `json:"sasaranRowsByType"`
+	OutOfAreaRows     int            `json:"outOfAreaRows"`
+	OutOfAreaByRule   map[string]int `json:"outOfAreaByRule"`
+	RejectedRows      int            `json:"rejectedRows"`
+	RejectedByReason  map[string]int `json:"rejectedByReason"`
+	BlankRows         int            `json:"blankRows"`  // blank rows skipped between data rows
+	FooterRows        int            `json:"footerRows"` // total or footer rows skipped, including the trailing ones
+	TableRange        string         `json:"tableRange"` // cell range of the source table, e.g. "A1:BH250"
+}
+
+// AddOutOfArea counts a source row dropped by the given wilayah rule.
+func (summary *GenerationSummary) AddOutOfArea(ruleName string) {
+	if summary.OutOfAreaByRule == nil {
+		summary.OutOfAreaByRule = make(map[string]int)
+	}
+	summary.OutOfAreaRows++
+	summary.OutOfAreaByRule[ruleName]++
 }
 
 // Sasaran represents sasaran imunisasi for both bayi and baduta
 type SasaranImunisasi struct {
 	NamaAnak         string                     `json:"namaAnak"`
 	UsiaAnak         string                     `json:"usiaAnak"`
-	TanggalLahirAnak string                     `json:"tanggalLahirAnak"`
+	TanggalLahirAnak time.Time                  `json:"tanggalLahirAnak"`
 	JenisKelaminAnak string                     `json:"jenisKelaminAnak"`
 	NamaOrangTua     string                     `json:"namaOrangTua"`
 	Puskesmas        string                     `json:"puskesmas"`
+	Posyandu         string                     `json:"posyandu"`
+	NomorHp          string                     `json:"nomorHp"` // parent's phone number, empty when unknown
+	SourceRow        int                        `json:"sourceRow"`
 	DetailImunisasi  map[string]DetailImunisasi `json:"detailImunisasi"`
+
+	// schedule data computed from jadwal imunisasi
+	StatusJadwal        map[string]StatusJadwal `json:"statusJadwal"`
+	ImunisasiBerikutnya string                  `json:"imunisasiBerikutnya"`
+	ImunisasiTerlambat  []string                `json:"imunisasiTerlambat"`
 }
 
 // DetailImunisasi represents detailed immunization data. Status ideal = 0 or non-ideal = 1
 // Non-ideal means the recipient has not yet received the immunization
+// Tanggal only holds the dates given in the source file.
 type DetailImunisasi struct {
-	Tanggal map[string]string
-	Pos     map[string]string
-	Status  map[string]int
+	Tanggal map[string]time.Time `json:"tanggal"`
+	Pos     map[string]string    `json:"pos"`
+	Status  map[string]int       `json:"status"`
 }
 
 // NewSasaranImunisasiService initializes a new instance of SasaranImunisasiService
-// with column mappings for bayi and baduta based on the given config.
-func NewSasaranImunisasiService(cfg *SasaranImunisasiConfig) *SasaranImunisasiService {
+// with column mappings for bayi and baduta based on the given config. The config is compiled first,
+// so an invalid rule, template or layout fails here instead of disabling its feature.
+func NewSasaranImunisasiService(cfg *SasaranImunisasiConfig) (*SasaranImunisasiService, error) {
+	if err := cfg.Compile(); err != nil {
+		return nil, err
+	}
 	sasaranBayiColumnMap := SetColumnMap(cfg, cfg.ImunisasiBayi)
 	sasaranBadutaColumnMap := SetColumnMap(cfg, cfg.ImunisasiBaduta)
 	return &SasaranImunisasiService{
 		Cfg:                    cfg,
 		SasaranBayiColumnMap:   sasaranBayiColumnMap,
 		SasaranBadutaColumnMap: sasaranBadutaColumnMap,
+		Clock:                  time.Now,
+		SourceHeaderNames:      cfg.GetSourceHeaderNames(),
+	}, nil
+}
+
+// NewFileGeneration initializes a new FileGeneration for the sasaran type and reference date carried
+// by the given context. The date of the service clock is used when the context does not carry a reference date.
+func (svc *SasaranImunisasiService) NewFileGeneration(ctx context.Context) *FileGeneration {
+	sasaranType := GetSasaranTypeFromContext(ctx)
+	tanggalAcuan, ok := GetTanggalAcuanFromContext(ctx)
+	if !ok {
+		tanggalAcuan = ToDate(svc.Clock())
+	}
+
+	fileGeneration := &FileGeneration{
+		Ctx:           ctx,
+		SasaranType:   sasaranType,
+		TanggalAcuan:  tanggalAcuan,
+		PisahPosyandu: GetPisahPosyanduFromContext(ctx),
+	}
+	statusImunisasi := GetStatusImunisasiFromContext(ctx)
+	if statusImunisasi == EMPTY_STRING {
+		statusImunisasi = svc.Cfg.StatusImunisasi
+	}
+	layout := GetLayoutFromContext(ctx)
+	if layout == EMPTY_STRING {
+		layout = svc.Cfg.Layout
+	}
+	for _, generationType := range GetGenerationTypes(sasaranType) {
+		generation := svc.NewGeneration(generationType, tanggalAcuan)
+		generation.StatusImunisasi = statusImunisasi
+		generation.Layout = layout
+		fileGeneration.Generations = append(fileGeneration.Generations, generation)
+	}
+	return fileGeneration
+}
+
+// GetGenerationTypes returns the sasaran types of the sheets generated for the requested sasaran type.
+func GetGenerationTypes(sasaranType string) []string {
+	if sasaranType == SEMUA {
+		return []string{BAYI, BADUTA}
+	}
+	return []string{sasaranType}
+}
+
+// NewGeneration initializes a new SasaranImunisasiGeneration for the given sasaran type and reference date.
+func (svc *SasaranImunisasiService) NewGeneration(sasaranType string, tanggalAcuan time.Time) *SasaranImunisasiGeneration {
+	sasaranColumnMap, lastColumnLabel := svc.GetSasaranColumnMap(sasaranType)
+	imunisasi := svc.Cfg.ImunisasiBaduta
+	if sasaranType == BAYI {
+		imunisasi = svc.Cfg.ImunisasiBayi
+	}
+	return &SasaranImunisasiGeneration{
+		Cfg:                  svc.Cfg,
+		SasaranType:          sasaranType,
+		TanggalAcuan:         tanggalAcuan,
+		SasaranColumnMap:     sasaranColumnMap,
+		LastColumnLabel:      lastColumnLabel,
+		Imunisasi:            imunisasi,
+		JadwalImunisasi:      svc.Cfg.GetJadwalImunisasi(imunisasi),
+		Cakupan:              NewCakupanImunisasi(imunisasi),
+		SasaranImunisasiList: []SasaranImunisasi{},
 	}
 }
 
@@ -48,131 +188,408 @@ func NewSasaranImunisasiService(cfg *SasaranImunisasiConfig) *SasaranImunisasiSe
 // based on the sasaran imunisasi data and column mappings.
 // Returns a pointer to the generated xlsx file and an error if the generation fails.
 func (svc *SasaranImunisasiService) GenerateFile(sourceFile XlsxSourceFile) (*XlsxGeneratedFile, error) {
-	sasaranImunisasiList := []SasaranImunisasi{} // initialize sasaran imunisasi list
+	fileGeneration, err := svc.ReadSourceFile(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	return fileGeneration.NewXlsxGeneratedFile()
+}
 
-	// retrieves column map
-	sasaranColumnMap, _ := svc.GetSasaranColumnMap(sourceFile.Ctx)
-	sourceColumnMap := svc.GetSourceColumnMap(sourceFile)
+// NewXlsxGeneratedFile creates the generated xlsx file of the file generation, together with the file of every
+// posyandu when the list is split into a ZIP.
+func (fileGeneration *FileGeneration) NewXlsxGeneratedFile() (*XlsxGeneratedFile, error) {
+	// create new xlsx file containing filtered data from source
+	excelFile, err := CreateNewXlsxWorkbook(fileGeneration.Ctx, fileGeneration.GetSheets())
+	if err != nil {
+		return nil, err
+	}
+
+	generatedFile := &XlsxGeneratedFile{
+		FileName:      GetFileName(fileGeneration.SasaranType, fileGeneration.TanggalAcuan) + ".xlsx",
+		ExcelizeFile:  excelFile,
+		RejectedRows:  fileGeneration.RejectedRows,
+		Summary:       fileGeneration.Summary,
+		PengingatList: fileGeneration.PengingatList,
+	}
+
+	// create one more xlsx file per posyandu to be sent together in a ZIP
+	if fileGeneration.PisahPosyandu == PISAH_POSYANDU_ZIP {
+		if generatedFile.PosyanduFiles, err = fileGeneration.GetPosyanduFiles(); err != nil {
+			return nil, err
+		}
+	}
+	return generatedFile, nil
+}
+
+// ReadSourceFile reads every row of the source sheet into a new FileGeneration. The first non-blank row is the
+// header, mapped to column indices once. Every row up to the end of the sheet is read: blank rows and total or
+// footer rows are skipped instead of ending the table. Once the rows are read, every generation is finished in
+// FinishGeneration and the rows are counted in the summary.
+func (svc *SasaranImunisasiService) ReadSourceFile(sourceFile XlsxSourceFile) (*FileGeneration, error) {
+	fileGeneration := svc.NewFileGeneration(sourceFile.Ctx)
+	rows, err := sourceFile.Reader.Rows(sourceFile.SheetName)
+	if err != nil {
+		log.Printf("Error reading sheet %q of the source file: %v", sourceFile.SheetName, err)
+		return nil, err
+	}
+	defer rows.Close()
 
-	rowIndex := 2
-	for {
-		// check end of file
-		if GetCellValue(sourceFile, A+strconv.Itoa(rowIndex)) == HYPHEN {
-			break
+	boundary := &TableBoundary{}
+	for rowIndex := 1; rows.Next(); rowIndex++ {
+		values, err := rows.Columns()
+		if err != nil {
+			log.Printf("Error reading row %d of the source file: %v", rowIndex, err)
+			return nil, err
 		}
+		row := SourceRow{Index: rowIndex, Values: values}
+
+		switch {
+		case row.IsBlank():
+			if boundary.HeaderRow != 0 {
+				boundary.AddBlankRow()
+			}
+		case boundary.HeaderRow == 0:
+			// retrieves column map
+			fileGeneration.SourceColumnMap = svc.GetSourceColumnMap(values)
+			boundary.SetHeader(row)
+			if err := svc.ValidateSourceColumns(sourceFile.SheetName, fileGeneration.SourceColumnMap); err != nil {
+				log.Printf("Invalid source file: %v", err)
+				return nil, err
+			}
+		case svc.Cfg.IsFooterRow(row, fileGeneration.SourceColumnMap):
+			boundary.AddFooterRow()
+		default:
+			// populate each rows data
+			boundary.AddDataRow(rowIndex)
+			svc.ReadRow(fileGeneration, row)
+		}
+	}
+	if err := rows.Error(); err != nil {
+		log.Printf("Error reading the source file: %v", err)
+		return nil, err
+	}
+
+	summary := &fileGeneration.Summary
+	summary.SheetName = sourceFile.SheetName
+	summary.BlankRows, summary.FooterRows, summary.TableRange = boundary.BlankRows, boundary.FooterRows, boundary.GetRange()
+	summary.SasaranRowsByType = make(map[string]int)
+	for _, generation := range fileGeneration.Generations {
+		fileGeneration.FinishGeneration(generation)
+		summary.SasaranRows += len(generation.SasaranImunisasiList)
+		summary.SasaranRowsByType[generation.SasaranType] = len(generation.SasaranImunisasiList)
+	}
+	fileGeneration.PengingatList = fileGeneration.GetPengingat()
+	log.Printf("Generated sasaran imunisasi %s: %v of %d source rows in %s, %d blank and %d footer rows skipped, %d out of wilayah %v, rejected %v",
+		fileGeneration.SasaranType, summary.SasaranRowsByType, summary.SourceRows, summary.TableRange, summary.BlankRows, summary.FooterRows,
+		summary.OutOfAreaRows, summary.OutOfAreaByRule, summary.RejectedByReason)
+
+	// duplicates are rejected after the rows read before them, list the rejected rows in source order
+	sort.SliceStable(fileGeneration.RejectedRows, func(i, j int) bool {
+		return fileGeneration.RejectedRows[i].RowNumber < fileGeneration.RejectedRows[j].RowNumber
+	})
+	return fileGeneration, nil
+}
+
+// ReadRow reads a single source row into the generation matching the child's sasaran type. Rows outside the
+// wilayah, without a name or with an unreadable birth date are rejected before the child is classified by age.
+// The child is checked against the other children of the generation once every row is read, in FinishGeneration.
+func (svc *SasaranImunisasiService) ReadRow(fileGeneration *FileGeneration, row SourceRow) {
+	rowIndex, getCellValue := row.Index, row.GetValue
+
+	fileGeneration.Summary.SourceRows++
+	if isInWilayah, excludedBy := svc.Cfg.Wilayah.CheckRow(fileGeneration.SourceColumnMap, getCellValue); !isInWilayah {
+		fileGeneration.Summary.AddOutOfArea(excludedBy)
+		fileGeneration.Reject(RejectedRow{
+			RowNumber: rowIndex,
+			NamaAnak:  getCellValue(fileGeneration.SourceColumnMap[NAMA_ANAK]),
+			Reason:    REASON_OUT_OF_AREA,
+			Detail:    excludedBy,
+		})
+		return
+	}
+
+	namaAnak := getCellValue(fileGeneration.SourceColumnMap[NAMA_ANAK])
+	if namaAnak == HYPHEN || strings.TrimSpace(namaAnak) == EMPTY_STRING {
+		fileGeneration.Reject(RejectedRow{RowNumber: rowIndex, NamaAnak: namaAnak, Reason: REASON_MISSING_NAME})
+		return
+	}
+
+	// route the child to the generation of their sasaran type based on their age
+	tanggalLahirAnak := getCellValue(fileGeneration.SourceColumnMap[TANGGAL_LAHIR_ANAK])
+	birthDate, err := svc.Cfg.ParseTanggal(tanggalLahirAnak)
+	if err != nil {
+		fileGeneration.Reject(RejectedRow{RowNumber: rowIndex, NamaAnak: namaAnak, Reason: REASON_INVALID_BIRTH_DATE, Detail: tanggalLahirAnak})
+		return
+	}
 
-		// populate each rows data
-		isRowValid, sasaranImunisasi := svc.PopulateRowsData(&DataRowPopulator{
-			SasaranColumnMap: sasaranColumnMap,
-			SourceColumnMap:  sourceColumnMap,
-			RowIndex:         rowIndex,
-			SourceFile:       sourceFile,
+	sasaranType := ClassifySasaranType(birthDate, fileGeneration.TanggalAcuan)
+	generation := fileGeneration.GetGeneration(sasaranType)
+	if generation == nil {
+		fileGeneration.Reject(RejectedRow{
+			RowNumber: rowIndex,
+			NamaAnak:  namaAnak,
+			Reason:    REASON_OUT_OF_AGE_RANGE,
+			Detail:    GetOutOfAgeRangeDetail(sasaranType, GetUsiaBulan(birthDate, fileGeneration.TanggalAcuan)),
 		})
-		rowIndex++
+		return
+	}
+
+	sasaranImunisasi := svc.PopulateRowsData(&DataRowPopulator{
+		SasaranColumnMap: generation.SasaranColumnMap,
+		SourceColumnMap:  fileGeneration.SourceColumnMap,
+		Row:              row,
+		TanggalAcuan:     generation.TanggalAcuan,
+	})
+
+	sasaranImunisasi.Posyandu = svc.GetPosyandu(sasaranImunisasi, generation.Imunisasi, fileGeneration.SourceColumnMap, getCellValue)
+	sasaranImunisasi.NomorHp = svc.GetNomorHp(fileGeneration.SourceColumnMap, getCellValue)
+	generation.SasaranImunisasiList = append(generation.SasaranImunisasiList, sasaranImunisasi)
+}
+
+// FinishGeneration merges the duplicates of the generation, then computes the jadwal imunisasi of every child
+// and counts them in the coverage, including the fully immunized ones which are rejected afterwards. The list
+// is sorted by tanggal lahir from the oldest to the youngest.
+func (fileGeneration *FileGeneration) FinishGeneration(gen *SasaranImunisasiGeneration) {
+	fileGeneration.MergeDuplicates(gen)
 
-		if isRowValid && sasaranImunisasi.CountNonIdealImmunizations() > 0 {
-			sasaranImunisasiList = append(sasaranImunisasiList, sasaranImunisasi)
+	sasaranImunisasiList := []SasaranImunisasi{}
+	for _, sasaranImunisasi := range gen.SasaranImunisasiList {
+		sasaranImunisasi.SetJadwalImunisasi(gen.JadwalImunisasi, gen.TanggalAcuan)
+		gen.AddCakupan(sasaranImunisasi)
+
+		if reason, detail := gen.GetRejectReason(sasaranImunisasi); reason != EMPTY_STRING {
+			fileGeneration.Reject(RejectedRow{
+				RowNumber:   sasaranImunisasi.SourceRow,
+				NamaAnak:    sasaranImunisasi.NamaAnak,
+				SasaranType: gen.SasaranType,
+				Reason:      reason,
+				Detail:      detail,
+			})
+			continue
 		}
+		sasaranImunisasiList = append(sasaranImunisasiList, sasaranImunisasi)
 	}
 
-	// sort sasaran imunisasi anak by tanggal lahir from the oldest to the youngest
-	SortByStrDate(sasaranImunisasiList, func(s SasaranImunisasi) string {
+	SortByDate(sasaranImunisasiList, func(s SasaranImunisasi) time.Time {
 		return s.TanggalLahirAnak
 	})
-	svc.SasaranImunisasiList = sasaranImunisasiList
+	gen.SasaranImunisasiList = sasaranImunisasiList
+}
 
-	// create new xlsx file containing filtered data from source
-	excelFile, err := CreateNewXlsxFile(sourceFile.Ctx, svc)
-	if err != nil {
-		return nil, err
+// GetSheets returns the sheets of the generated file. A single sasaran type without a per-posyandu split fills the
+// default sheet, otherwise an index sheet is followed by the sasaran sheets. The coverage sheets, the reminder sheet
+// when reminders are configured and the duplicate sheet follow and the rejected rows sheet comes last.
+func (fileGeneration *FileGeneration) GetSheets() []XlsxSheet {
+	sheets := []XlsxSheet{}
+	sasaranSheets := fileGeneration.GetSasaranSheets()
+	isSingle := len(fileGeneration.Generations) == 1
+	if !isSingle || fileGeneration.PisahPosyandu == PISAH_POSYANDU_SHEET {
+		sheets = append(sheets, XlsxSheet{Name: INDEX_SHEET_NAME, Generator: fileGeneration.NewIndexSheet(sasaranSheets)})
 	}
+	sheets = append(sheets, sasaranSheets...)
 
-	return &XlsxGeneratedFile{
-		FileName:     svc.GetFileName(GetSasaranTypeFromContext(sourceFile.Ctx)) + ".xlsx",
-		ExcelizeFile: excelFile,
-	}, nil
+	for _, generation := range fileGeneration.Generations {
+		sheets = append(sheets, XlsxSheet{Name: generation.GetCakupanSheetName(isSingle), Generator: generation.NewCakupanSheet()})
+	}
+
+	if fileGeneration.Generations[0].Cfg.Pengingat.IsEnabled() {
+		sheets = append(sheets, XlsxSheet{Name: PENGINGAT_SHEET_NAME, Generator: &PengingatSheet{
+			Title:         GetPengingatTitle(fileGeneration.SasaranType, fileGeneration.TanggalAcuan),
+			PengingatList: fileGeneration.PengingatList,
+		}})
+	}
+	sheets = append(sheets, XlsxSheet{Name: DUPLIKAT_SHEET_NAME, Generator: &DuplicateSheet{
+		Title:      GetDuplicateTitle(fileGeneration.SasaranType, fileGeneration.TanggalAcuan),
+		Duplicates: fileGeneration.Duplicates,
+	}})
+	return append(sheets, XlsxSheet{Name: REJECTED_SHEET_NAME, Generator: &RejectedRowsSheet{
+		Title:        GetRejectedTitle(fileGeneration.SasaranType, fileGeneration.TanggalAcuan),
+		RejectedRows: fileGeneration.RejectedRows,
+	}})
 }
 
-// GetFileName returns title based on sasaranType
-func (svc *SasaranImunisasiService) GetFileName(sasaranType string) string {
-	return "Sasaran Imunisasi " + CapitalizeFirstChar(sasaranType) + SPACE + GetCurrentDateStr()
+// GetSasaranSheets returns the sasaran sheets of the generated file, one per sasaran type or, when the list
+// is split per posyandu, one per sasaran type and posyandu.
+func (fileGeneration *FileGeneration) GetSasaranSheets() []XlsxSheet {
+	isSingle := len(fileGeneration.Generations) == 1
+	if fileGeneration.PisahPosyandu != PISAH_POSYANDU_SHEET {
+		if isSingle {
+			return []XlsxSheet{{Name: SHEET_NAME, Generator: fileGeneration.Generations[0]}}
+		}
+		sheets := []XlsxSheet{}
+		for _, generation := range fileGeneration.Generations {
+			sheets = append(sheets, XlsxSheet{Name: generation.GetSheetName(), Generator: generation})
+		}
+		return sheets
+	}
+
+	// reserve the names of the other sheets so posyandu names never collide with them
+	usedNames := map[string]bool{
+		strings.ToLower(INDEX_SHEET_NAME):     true,
+		strings.ToLower(PENGINGAT_SHEET_NAME): true,
+		strings.ToLower(DUPLIKAT_SHEET_NAME):  true,
+		strings.ToLower(REJECTED_SHEET_NAME):  true,
+	}
+	for _, generation := range fileGeneration.Generations {
+		usedNames[strings.ToLower(generation.GetCakupanSheetName(isSingle))] = true
+	}
+
+	sheets := []XlsxSheet{}
+	for _, generation := range fileGeneration.Generations {
+		for _, posyanduGeneration := range generation.SplitByPosyandu() {
+			sheetName := posyanduGeneration.Posyandu
+			if !isSingle {
+				sheetName = generation.GetSheetName() + SPACE + sheetName
+			}
+			sheets = append(sheets, XlsxSheet{Name: GetValidSheetName(sheetName, usedNames), Generator: posyanduGeneration})
+		}
+	}
+	return sheets
 }
 
-// SetTitle sets the title of the Excel sheet for the generated file
-func (svc *SasaranImunisasiService) SetTitle(newFile NewXlsxFile) {
-	file := newFile.ExcelizeFile
-	sheetName := newFile.SheetName
-	rowAt := strconv.Itoa(newFile.TitleRowAt)
-	sasaranImunisasiMap, lastColumnLabel := svc.GetSasaranColumnMap(newFile.Ctx)
+// GetSheetName returns the sheet name of the generation when several sasaran types share one file, e.g. "Bayi".
+func (gen *SasaranImunisasiGeneration) GetSheetName() string {
+	return CapitalizeFirstChar(gen.SasaranType)
+}
 
-	firstCell := sasaranImunisasiMap[NAMA_ANAK].Label + rowAt
-	lastCell := lastColumnLabel + rowAt
+// GetTitle returns the title of the generated sheet, followed by the posyandu when the list is split per posyandu.
+func (gen *SasaranImunisasiGeneration) GetTitle() string {
+	if gen.Posyandu != EMPTY_STRING {
+		return GetFileName(gen.SasaranType, gen.TanggalAcuan) + SPACE + gen.Posyandu
+	}
+	return GetFileName(gen.SasaranType, gen.TanggalAcuan)
+}
 
-	file.SetCellValue(sheetName, firstCell, svc.GetFileName(GetSasaranTypeFromContext(newFile.Ctx)))
-	file.MergeCell(sheetName, firstCell, lastCell)
-	file.SetCellStyle(sheetName, firstCell, lastCell, newFile.TitleStyle)
+// GetFileName returns title based on sasaranType and the reference date
+func GetFileName(sasaranType string, tanggalAcuan time.Time) string {
+	return "Sasaran Imunisasi " + CapitalizeFirstChar(sasaranType) + SPACE + GetDateStr(tanggalAcuan)
 }
 
-// SetHeader sets the header row of the Excel sheet
-func (svc *SasaranImunisasiService) SetHeader(newFile NewXlsxFile) {
-	file := newFile.ExcelizeFile
-	sheetName := newFile.SheetName
-	rowAt := strconv.Itoa(newFile.HeaderRowAt)
-	sasaranImunisasiMap, lastColumnLabel := svc.GetSasaranColumnMap(newFile.Ctx)
+// SetTitle sets the title of the Excel sheet for the generated file, followed by the legend of the status colors
+func (gen *SasaranImunisasiGeneration) SetTitle(newFile NewXlsxFile) {
+	newFile.SetTitleRow(gen.GetTitle(), gen.GetColumnCount())
+	gen.SetStatusLegend(newFile)
+}
 
-	for name, column := range sasaranImunisasiMap {
-		file.SetCellValue(sheetName, column.Label+rowAt, name)
+// SetHeader sets the header row of the Excel sheet, high enough for the longest header wrapped to its column
+func (gen *SasaranImunisasiGeneration) SetHeader(newFile NewXlsxFile) {
+	header := gen.GetHeader()
+	lines := 1
+	for i, width := range gen.GetColumnWidths() {
+		lines = max(lines, GetWrappedLineCount(header[i], width))
 	}
+	newFile.SetRow(newFile.HeaderRowAt, ToInterfaces(header), newFile.HeaderStyle, excelize.RowOpts{Height: float64(lines) * XLSX_LINE_HEIGHT})
+}
 
-	firstBodyCell := sasaranImunisasiMap[NAMA_ANAK].Label + rowAt
-	lastBodyCell := lastColumnLabel + rowAt
+// SetBody sets the body row of the Excel sheet, the status cells colored by value
+func (gen *SasaranImunisasiGeneration) SetBody(newFile NewXlsxFile) {
+	for i, sasaranImunisasi := range gen.SasaranImunisasiList {
+		newFile.SetRow(i+newFile.StartBodyRowAt, gen.GetRow(sasaranImunisasi), newFile.BodyStyle)
+	}
+	gen.SetStatusFormatting(newFile)
+}
 
-	file.SetCellStyle(sheetName, firstBodyCell, lastBodyCell, newFile.HeaderStyle)
+// GetHeader returns the header of the generated sheet in its layout, ordered by column
+func (gen *SasaranImunisasiGeneration) GetHeader() []string {
+	if gen.IsRingkas() {
+		return gen.GetRingkasHeader()
+	}
+	return gen.GetLengkapHeader()
 }
 
-// SetBody sets the body row of the Excel sheet
-func (svc *SasaranImunisasiService) SetBody(newFile NewXlsxFile) {
-	file := newFile.ExcelizeFile
-	sheetName := newFile.SheetName
-	sasaranImunisasiMap, lastColumnLabel := svc.GetSasaranColumnMap(newFile.Ctx)
+// GetRow returns the values of the row of a child in the generated sheet in its layout, ordered by column.
+func (gen *SasaranImunisasiGeneration) GetRow(sasaranImunisasi SasaranImunisasi) []interface{} {
+	if gen.IsRingkas() {
+		return gen.GetRingkasRow(sasaranImunisasi)
+	}
+	return gen.GetLengkapRow(sasaranImunisasi)
+}
 
-	for i, sasaranImunisasi := range svc.SasaranImunisasiList {
-		rowAt := strconv.Itoa(i + newFile.StartBodyRowAt)
-		file.SetCellValue(sheetName, sasaranImunisasiMap[NAMA_ANAK].Label+rowAt, sasaranImunisasi.NamaAnak)
-		file.SetCellValue(sheetName, sasaranImunisasiMap[USIA_ANAK].Label+rowAt, sasaranImunisasi.UsiaAnak)
-		file.SetCellValue(sheetName, sasaranImunisasiMap[TANGGAL_LAHIR_ANAK].Label+rowAt, sasaranImunisasi.TanggalLahirAnak)
-		file.SetCellValue(sheetName, sasaranImunisasiMap[JENIS_KELAMIN_ANAK].Label+rowAt, sasaranImunisasi.JenisKelaminAnak)
-		file.SetCellValue(sheetName, sasaranImunisasiMap[NAMA_ORANG_TUA].Label+rowAt, sasaranImunisasi.NamaOrangTua)
-		file.SetCellValue(sheetName, sasaranImunisasiMap[PUSKESMAS].Label+rowAt, sasaranImunisasi.Puskesmas)
-		for _, detailImunisasi := range sasaranImunisasi.DetailImunisasi {
-			for key, value := range detailImunisasi.Tanggal {
-				cell := sasaranImunisasiMap[key].Label + rowAt
-				file.SetCellValue(sheetName, cell, value)
-			}
+// GetLengkapHeader returns the header of every column of the column map, ordered by column
+func (gen *SasaranImunisasiGeneration) GetLengkapHeader() []string {
+	header := make([]string, gen.getLengkapColumnCount())
+	for name, column := range gen.SasaranColumnMap {
+		header[GetColumnIndex(column.Label)] = name
+	}
+	return header
+}
 
-			for key, value := range detailImunisasi.Pos {
-				cell := sasaranImunisasiMap[key].Label + rowAt
-				file.SetCellValue(sheetName, cell, value)
-			}
+// GetLengkapRow returns the values of the row of a child in every column of the column map, ordered by column.
+// Columns without a value hold "-", except the status columns which hold the label of every antigen in the label mode.
+func (gen *SasaranImunisasiGeneration) GetLengkapRow(sasaranImunisasi SasaranImunisasi) []interface{} {
+	values := make([]interface{}, gen.getLengkapColumnCount())
+	for j := range values {
+		values[j] = HYPHEN
+	}
+	setValue := func(name string, value interface{}) {
+		if column, exists := gen.SasaranColumnMap[name]; exists {
+			values[GetColumnIndex(column.Label)] = value
+		}
+	}
 
-			for key, value := range detailImunisasi.Status {
-				cell := sasaranImunisasiMap[key].Label + rowAt
-				file.SetCellValue(sheetName, cell, value)
-			}
+	setValue(NAMA_ANAK, sasaranImunisasi.NamaAnak)
+	setValue(USIA_ANAK, sasaranImunisasi.UsiaAnak)
+	setValue(TANGGAL_LAHIR_ANAK, sasaranImunisasi.TanggalLahirAnak)
+	setValue(JENIS_KELAMIN_ANAK, sasaranImunisasi.JenisKelaminAnak)
+	setValue(NAMA_ORANG_TUA, sasaranImunisasi.NamaOrangTua)
+	setValue(PUSKESMAS, sasaranImunisasi.Puskesmas)
+	setValue(IMUNISASI_BERIKUTNYA, sasaranImunisasi.ImunisasiBerikutnya)
+	setValue(TERLAMBAT, sasaranImunisasi.GetTerlambatStr())
+	for _, detailImunisasi := range sasaranImunisasi.DetailImunisasi {
+		for key, value := range detailImunisasi.Tanggal {
+			setValue(key, value)
 		}
 
-		firstBodyCell := sasaranImunisasiMap[NAMA_ANAK].Label + rowAt
-		lastBodyCell := lastColumnLabel + rowAt
-		file.SetCellStyle(sheetName, firstBodyCell, lastBodyCell, newFile.BodyStyle)
+		for key, value := range detailImunisasi.Pos {
+			setValue(key, value)
+		}
+
+		for key, value := range detailImunisasi.Status {
+			setValue(key, value)
+		}
+	}
+	if gen.IsStatusLabel() {
+		for imun, name := range gen.GetStatusColumns() {
+			setValue(name, sasaranImunisasi.GetStatusLabel(imun))
+		}
 	}
+	return values
 }
 
-// SetColumnWidth sets the column width of the Excel sheet
-func (svc *SasaranImunisasiService) SetColumnWidth(newFile NewXlsxFile) {
-	file := newFile.ExcelizeFile
-	sheetName := newFile.SheetName
-	sasaranImunisasiMap, _ := svc.GetSasaranColumnMap(newFile.Ctx)
-	for _, column := range sasaranImunisasiMap {
-		file.SetColWidth(sheetName, column.Label, column.Label, 32)
+// SetColumnWidth sets the column width of the Excel sheet, fitted to the content when configured
+func (gen *SasaranImunisasiGeneration) SetColumnWidth(newFile NewXlsxFile) {
+	newFile.SetColumnWidths(gen.GetColumnWidths())
+}
+
+// GetColumnWidths returns the width of every column of the generated sheet, fitted to its header and rows when
+// configured, otherwise the default width.
+func (gen *SasaranImunisasiGeneration) GetColumnWidths() []float64 {
+	cfg := &gen.Cfg.XlsxLayout
+	if !cfg.AutoWidth {
+		return slices.Repeat([]float64{XLSX_COLUMN_WIDTH}, gen.GetColumnCount())
+	}
+
+	rows := make([][]interface{}, len(gen.SasaranImunisasiList))
+	for i, sasaranImunisasi := range gen.SasaranImunisasiList {
+		rows[i] = gen.GetRow(sasaranImunisasi)
 	}
+	return cfg.GetColumnWidths(gen.GetHeader(), rows)
+}
+
+// GetXlsxLayout returns the configured layout of the generated sheet. It implements XlsxTableGenerator.
+func (gen *SasaranImunisasiGeneration) GetXlsxLayout() XlsxLayout {
+	return XlsxLayout{Cfg: &gen.Cfg.XlsxLayout, ColumnCount: gen.GetColumnCount(), RowCount: len(gen.SasaranImunisasiList)}
+}
+
+// GetColumnCount returns the number of columns of the generated sheet in its layout
+func (gen *SasaranImunisasiGeneration) GetColumnCount() int {
+	if gen.IsRingkas() {
+		return len(gen.GetRingkasHeader())
+	}
+	return gen.getLengkapColumnCount()
+}
+
+// getLengkapColumnCount returns the number of columns of the column map, up to the last column label
+func (gen *SasaranImunisasiGeneration) getLengkapColumnCount() int {
+	return GetColumnIndex(gen.LastColumnLabel) + 1
 }
@@ -0,0 +1,518 @@
+package sasaranimunisasi
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// goldenChild describes a child of the golden source export, the first Diberikan antigens are given.
+// PosTerakhir is the pos of the last given antigen, the others are given at Posyandu Wanasari.
+type goldenChild struct {
+	NamaAnak         string
+	TanggalLahirAnak string
+	Diberikan        int
+	PosTerakhir      string
+}
+
+var goldenChildren = []goldenChild{
+	{NamaAnak: "Ahmad", TanggalLahirAnak: "2024-03-02", Diberikan: 4},
+	{NamaAnak: "Siti", TanggalLahirAnak: "2024-01-15", Diberikan: 0},
+	{NamaAnak: "Budi", TanggalLahirAnak: "2024-06-20", Diberikan: 9, PosTerakhir: "Posyandu Melati"},
+	{NamaAnak: "Dewi", TanggalLahirAnak: "2023-05-01", Diberikan: 2, PosTerakhir: "Posyandu Melati"},
+	{NamaAnak: "Rina", TanggalLahirAnak: "2024-01-15", Diberikan: 30},
+	{NamaAnak: "ahmad ", TanggalLahirAnak: "2024-03-02", Diberikan: 1},
+	{NamaAnak: "Joko", TanggalLahirAnak: "02/01/2024", Diberikan: 1},
+	{NamaAnak: "Tono", TanggalLahirAnak: "2021-08-17", Diberikan: 3},
+}
+
+// newGoldenSourceXlsx builds a deterministic source export containing goldenChildren for the given antigens.
+func newGoldenSourceXlsx(t testing.TB, cfg *SasaranImunisasiConfig, imunisasi []string) XlsxSourceFile {
+	t.Helper()
+	file := excelize.NewFile()
+
+	header := []string{NAMA_ANAK, TANGGAL_LAHIR_ANAK, JENIS_KELAMIN_ANAK, NAMA_ORANG_TUA, PUSKESMAS}
+	for _, imun := range imunisasi {
+		details := cfg.DetailImunisasi
+		if imun == IDL_1 || imun == IBL_1 {
+			details = cfg.DetailImunisasiLengkap
+		}
+		for _, detail := range details {
+			header = append(header, detail+SPACE+imun)
+		}
+	}
+	for col, name := range header {
+		file.SetCellValue(SHEET_NAME, GetXlsxColumnLabel(col+1)+"1", name)
+	}
+
+	for i, child := range goldenChildren {
+		row := i + 2
+		birthDate, _ := cfg.ParseTanggal(child.TanggalLahirAnak)
+		for col, name := range header {
+			cell := fmt.Sprintf("%s%d", GetXlsxColumnLabel(col+1), row)
+			imunIndex := (col - 5) / len(cfg.DetailImunisasi)
+			given := col >= 5 && imunIndex < child.Diberikan
+			switch {
+			case name == NAMA_ANAK:
+				file.SetCellValue(SHEET_NAME, cell, child.NamaAnak)
+			case name == TANGGAL_LAHIR_ANAK:
+				file.SetCellValue(SHEET_NAME, cell, child.TanggalLahirAnak)
+			case name == JENIS_KELAMIN_ANAK:
+				file.SetCellValue(SHEET_NAME, cell, []string{"Laki-laki", "Perempuan"}[i%2])
+			case name == NAMA_ORANG_TUA:
+				file.SetCellValue(SHEET_NAME, cell, "Ibu "+strings.TrimSpace(child.NamaAnak))
+			case name == PUSKESMAS:
+				file.SetCellValue(SHEET_NAME, cell, "Puskesmas Wanasari")
+			case given && strings.HasPrefix(name, TANGGAL):
+				file.SetCellValue(SHEET_NAME, cell, birthDate.AddDate(0, imunIndex, 0).Format("2006-01-02"))
+			case given && strings.HasPrefix(name, POS) && imunIndex == child.Diberikan-1 && child.PosTerakhir != EMPTY_STRING:
+				file.SetCellValue(SHEET_NAME, cell, child.PosTerakhir)
+			case given && strings.HasPrefix(name, POS):
+				file.SetCellValue(SHEET_NAME, cell, "Posyandu Wanasari")
+			case strings.HasPrefix(name, STATUS):
+				file.SetCellValue(SHEET_NAME, cell, map[bool]string{true: "ideal", false: "belum"}[given])
+			}
+		}
+	}
+
+	return XlsxSourceFile{
+		Ctx:       context.Background(),
+		SheetName: SHEET_NAME,
+		Reader:    NewXlsxReader(file),
+	}
+}
+
+// sheetToText renders every row of the sheet as tab separated values.
+func sheetToText(t testing.TB, file *excelize.File, sheetName string) string {
+	t.Helper()
+	rows, err := file.GetRows(sheetName)
+	if err != nil {
+		t.Fatalf("reading rows of %s: %v", sheetName, err)
+	}
+
+	var buf bytes.Buffer
+	for _, row := range rows {
+		buf.WriteString(strings.Join(row, "\t"))
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// workbookToText renders every sheet of the workbook, each preceded by its name.
+func workbookToText(t testing.TB, file *excelize.File) string {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, sheetName := range file.GetSheetList() {
+		buf.WriteString("# " + sheetName + "\n")
+		buf.WriteString(sheetToText(t, file, sheetName))
+	}
+	return buf.String()
+}
+
+// assertGolden compares got with the content of testdata/name, rewriting it when -update is set.
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("updating golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("generated file differs from %s (run go test -update to refresh)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestNewSasaranImunisasiServiceInvalidConfig(t *testing.T) {
+	cfg := loadTestConfig(t)
+	cfg.Wilayah.Exclude = []WilayahRule{{Name: "rt tidak valid", Column: "Alamat", Match: WILAYAH_MATCH_REGEX, Values: []string{"RT ("}}}
+	if _, err := NewSasaranImunisasiService(cfg); err == nil || !strings.Contains(err.Error(), "rt tidak valid") {
+		t.Errorf("got error %v, want the invalid wilayah rule", err)
+	}
+
+	cfg = loadTestConfig(t)
+	cfg.Layout = "pendek"
+	if _, err := NewSasaranImunisasiService(cfg); err == nil {
+		t.Errorf("got no error for an invalid layout")
+	}
+
+	cfg = loadTestConfig(t)
+	cfg.HariSegera = -1
+	if _, err := NewSasaranImunisasiService(cfg); err == nil || !strings.Contains(err.Error(), "hari_segera") {
+		t.Errorf("got error %v, want the invalid hari_segera", err)
+	}
+}
+
+func TestGenerateFileGolden(t *testing.T) {
+	cfg := loadTestConfig(t)
+	svc := newTestService(t, cfg)
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name          string
+		sasaranType   string
+		imunisasi     []string
+		tanggalAcuan  string
+		pisahPosyandu string
+		layout        string
+		golden        string
+		fileName      string
+	}{
+		{
+			name:        "bayi with service clock",
+			sasaranType: BAYI,
+			imunisasi:   cfg.ImunisasiBayi,
+			golden:      "bayi.golden",
+			fileName:    "Sasaran Imunisasi Bayi 3 Oktober.xlsx",
+		},
+		{
+			name:         "bayi with tanggal acuan",
+			sasaranType:  BAYI,
+			imunisasi:    cfg.ImunisasiBayi,
+			tanggalAcuan: "2024-10-15",
+			golden:       "bayi_tanggal_acuan.golden",
+			fileName:     "Sasaran Imunisasi Bayi 15 Oktober.xlsx",
+		},
+		{
+			name:        "baduta with service clock",
+			sasaranType: BADUTA,
+			imunisasi:   cfg.ImunisasiBaduta,
+			golden:      "baduta.golden",
+			fileName:    "Sasaran Imunisasi Baduta 3 Oktober.xlsx",
+		},
+		{
+			name:        "semua with service clock",
+			sasaranType: SEMUA,
+			imunisasi:   append(append([]string{}, cfg.ImunisasiBayi...), cfg.ImunisasiBaduta...),
+			golden:      "semua.golden",
+			fileName:    "Sasaran Imunisasi Semua 3 Oktober.xlsx",
+		},
+		{
+			name:          "bayi split per posyandu",
+			sasaranType:   BAYI,
+			imunisasi:     cfg.ImunisasiBayi,
+			pisahPosyandu: PISAH_POSYANDU_SHEET,
+			golden:        "bayi_pisah_posyandu.golden",
+			fileName:      "Sasaran Imunisasi Bayi 3 Oktober.xlsx",
+		},
+		{
+			name:          "semua split per posyandu",
+			sasaranType:   SEMUA,
+			imunisasi:     append(append([]string{}, cfg.ImunisasiBayi...), cfg.ImunisasiBaduta...),
+			pisahPosyandu: PISAH_POSYANDU_SHEET,
+			golden:        "semua_pisah_posyandu.golden",
+			fileName:      "Sasaran Imunisasi Semua 3 Oktober.xlsx",
+		},
+		{
+			name:        "bayi ringkas",
+			sasaranType: BAYI,
+			imunisasi:   cfg.ImunisasiBayi,
+			layout:      LAYOUT_RINGKAS,
+			golden:      "bayi_ringkas.golden",
+			fileName:    "Sasaran Imunisasi Bayi 3 Oktober.xlsx",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceFile := newGoldenSourceXlsx(t, cfg, tt.imunisasi)
+			defer sourceFile.Reader.Close()
+
+			ctx := context.WithValue(context.Background(), sasaranTypeKey, tt.sasaranType)
+			if tt.tanggalAcuan != EMPTY_STRING {
+				tanggalAcuan, _ := time.Parse("2006-01-02", tt.tanggalAcuan)
+				ctx = context.WithValue(ctx, tanggalAcuanKey, tanggalAcuan)
+			}
+			ctx = context.WithValue(ctx, pisahPosyanduKey, tt.pisahPosyandu)
+			sourceFile.Ctx = context.WithValue(ctx, layoutKey, tt.layout)
+
+			generatedFile, err := svc.GenerateFile(sourceFile)
+			if err != nil {
+				t.Fatalf("generating file: %v", err)
+			}
+			defer generatedFile.ExcelizeFile.Close()
+
+			if generatedFile.FileName != tt.fileName {
+				t.Errorf("got file name %q, want %q", generatedFile.FileName, tt.fileName)
+			}
+			assertGolden(t, tt.golden, workbookToText(t, generatedFile.ExcelizeFile))
+		})
+	}
+}
+
+func TestNewFileGenerationClock(t *testing.T) {
+	svc := newTestService(t, loadTestConfig(t))
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 15, 30, 0, 0, time.UTC) }
+	ctx := context.WithValue(context.Background(), sasaranTypeKey, BAYI)
+
+	// HB0 of a child born on September 26 is due until October 3, whether the date comes from the clock or
+	// from the request
+	for name, ctx := range map[string]context.Context{
+		"clock":   ctx,
+		"request": context.WithValue(ctx, tanggalAcuanKey, date(2024, time.October, 3)),
+	} {
+		gen := svc.NewFileGeneration(ctx).Generations[0]
+		if !gen.TanggalAcuan.Equal(date(2024, time.October, 3)) {
+			t.Errorf("%s: got tanggal acuan %s, want the date of the clock", name, gen.TanggalAcuan)
+		}
+		sasaranImunisasi := SasaranImunisasi{TanggalLahirAnak: date(2024, time.September, 26)}
+		sasaranImunisasi.SetJadwalImunisasi(gen.JadwalImunisasi, gen.TanggalAcuan)
+		if got := sasaranImunisasi.StatusJadwal["HB0"]; got != JADWAL_JATUH_TEMPO {
+			t.Errorf("%s: got HB0 %q on its last day, want %q", name, got, JADWAL_JATUH_TEMPO)
+		}
+	}
+}
+
+func TestGenerateFilePosyanduZip(t *testing.T) {
+	cfg := loadTestConfig(t)
+	svc := newTestService(t, cfg)
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC) }
+
+	sourceFile := newGoldenSourceXlsx(t, cfg, cfg.ImunisasiBayi)
+	defer sourceFile.Reader.Close()
+	ctx := context.WithValue(context.Background(), sasaranTypeKey, BAYI)
+	sourceFile.Ctx = context.WithValue(ctx, pisahPosyanduKey, PISAH_POSYANDU_ZIP)
+
+	generatedFile, err := svc.GenerateFile(sourceFile)
+	if err != nil {
+		t.Fatalf("generating file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	for _, posyanduFile := range generatedFile.PosyanduFiles {
+		buf.WriteString("## " + posyanduFile.FileName + "\n")
+		buf.WriteString(workbookToText(t, posyanduFile.ExcelizeFile))
+	}
+	assertGolden(t, "bayi_posyandu_zip.golden", buf.String())
+}
+
+// newBenchmarkSourceRows builds the header and rows of a district-sized source export of bayi for the given
+// antigens, every other antigen given.
+func newBenchmarkSourceRows(cfg *SasaranImunisasiConfig, imunisasi []string, rows int) [][]string {
+	header := []string{NAMA_ANAK, TANGGAL_LAHIR_ANAK, JENIS_KELAMIN_ANAK, NAMA_ORANG_TUA, PUSKESMAS}
+	for _, imun := range imunisasi {
+		header = append(header, cfg.GetDetailColumnNames(imun)...)
+	}
+	sourceRows := [][]string{header}
+
+	for row := 2; row <= rows+1; row++ {
+		birthDate := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, row%270)
+		values := []string{
+			fmt.Sprintf("Anak %d", row),
+			birthDate.Format("2006-01-02"),
+			[]string{"Laki-laki", "Perempuan"}[row%2],
+			fmt.Sprintf("Ibu %d", row),
+			"Puskesmas Wanasari",
+		}
+		for i := range imunisasi {
+			if (i+row)%2 == 0 {
+				values = append(values, birthDate.AddDate(0, i, 0).Format("2006-01-02"), "Posyandu Wanasari", "ideal")
+			} else {
+				values = append(values, EMPTY_STRING, EMPTY_STRING, "belum")
+			}
+		}
+		sourceRows = append(sourceRows, values)
+	}
+	return sourceRows
+}
+
+// newBenchmarkSourceXlsx writes the source rows as an xlsx export with a stream writer, to keep the fixture
+// cheap to build.
+func newBenchmarkSourceXlsx(b *testing.B, sourceRows [][]string) []byte {
+	b.Helper()
+	file := excelize.NewFile()
+	defer file.Close()
+	streamWriter, err := file.NewStreamWriter(SHEET_NAME)
+	if err != nil {
+		b.Fatalf("creating stream writer: %v", err)
+	}
+
+	for i, values := range sourceRows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := streamWriter.SetRow(cell, ToInterfaces(values)); err != nil {
+			b.Fatalf("writing row %d: %v", i+1, err)
+		}
+	}
+	if err := streamWriter.Flush(); err != nil {
+		b.Fatalf("flushing stream writer: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := file.Write(&buf); err != nil {
+		b.Fatalf("writing source xlsx: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newBenchmarkSourceCsv writes the source rows as a CSV export.
+func newBenchmarkSourceCsv(b *testing.B, sourceRows [][]string) []byte {
+	b.Helper()
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(sourceRows); err != nil {
+		b.Fatalf("writing source csv: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// BenchmarkGenerateFile measures opening an uploaded 50k-row export, from xlsx and from CSV, and writing the
+// generated file.
+func BenchmarkGenerateFile(b *testing.B) {
+	cfg := loadTestConfig(b)
+	svc := newTestService(b, cfg)
+	sourceRows := newBenchmarkSourceRows(cfg, cfg.ImunisasiBayi, 50000)
+	tanggalAcuan := time.Date(2024, time.October, 3, 0, 0, 0, 0, time.UTC)
+	ctx := context.WithValue(context.WithValue(context.Background(), sasaranTypeKey, BAYI), tanggalAcuanKey, tanggalAcuan)
+
+	for _, format := range []string{FORMAT_XLSX, FORMAT_CSV} {
+		content := newBenchmarkSourceXlsx(b, sourceRows)
+		if format == FORMAT_CSV {
+			content = newBenchmarkSourceCsv(b, sourceRows)
+		}
+		path := filepath.Join(b.TempDir(), "export."+format)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			b.Fatalf("writing source file: %v", err)
+		}
+
+		b.Run(format, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				reader, err := OpenSourceReader(path)
+				if err != nil {
+					b.Fatalf("opening source: %v", err)
+				}
+				generatedFile, err := svc.GenerateFile(XlsxSourceFile{Ctx: ctx, SheetName: SHEET_NAME, Reader: reader})
+				if err != nil {
+					b.Fatalf("generating file: %v", err)
+				}
+				if err := generatedFile.ExcelizeFile.Write(io.Discard); err != nil {
+					b.Fatalf("writing generated file: %v", err)
+				}
+				generatedFile.ExcelizeFile.Close()
+				reader.Close()
+			}
+		})
+	}
+}
+
+// TestReadSourceFileTableBoundary checks blank rows, a blank header column and total rows do not truncate the table.
+func TestReadSourceFileTableBoundary(t *testing.T) {
+	cfg := loadTestConfig(t)
+	svc := newTestService(t, cfg)
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC) }
+
+	file := excelize.NewFile()
+	defer file.Close()
+	rows := [][]interface{}{
+		{NAMA_ANAK, nil, TANGGAL_LAHIR_ANAK, NAMA_ORANG_TUA, "Status Imunisasi HB0"},
+		{"Ahmad", nil, "2024-03-02", "Ibu Ahmad", "belum"},
+		{},
+		{nil, nil, nil},
+		{"Siti", nil, "2024-01-15", "Ibu Siti", "belum"},
+		{"Jumlah Sasaran", nil, nil, nil, 2},
+		{"Total Agustus", nil, "2024-08-01", "Ibu Total", "belum"},
+		{},
+		{"Dicetak oleh admin"},
+	}
+	for i, values := range rows {
+		file.SetSheetRow(SHEET_NAME, fmt.Sprintf("A%d", i+1), &values)
+	}
+	file.SetCellStyle(SHEET_NAME, "A12", "E12", SetXlsxStyle(file, false))
+
+	fileGeneration, err := svc.ReadSourceFile(XlsxSourceFile{
+		Ctx:       context.WithValue(context.Background(), sasaranTypeKey, BAYI),
+		SheetName: SHEET_NAME,
+		Reader:    NewXlsxReader(file),
+	})
+	if err != nil {
+		t.Fatalf("reading source file: %v", err)
+	}
+
+	var names []string
+	for _, sasaranImunisasi := range fileGeneration.Generations[0].SasaranImunisasiList {
+		names = append(names, sasaranImunisasi.NamaAnak)
+	}
+	if strings.Join(names, ",") != "Siti,Ahmad,Total Agustus" {
+		t.Errorf("got children %v, want Siti, Ahmad and Total Agustus", names)
+	}
+	if _, exists := fileGeneration.SourceColumnMap[NAMA_ORANG_TUA]; !exists {
+		t.Errorf("got source column map %v, want the columns after the blank header", fileGeneration.SourceColumnMap)
+	}
+
+	summary := fileGeneration.Summary
+	if summary.SourceRows != 3 || summary.BlankRows != 2 || summary.FooterRows != 2 || summary.TableRange != "A1:E7" {
+		t.Errorf("got source rows %d, blank rows %d, footer rows %d, range %q, want 3, 2, 2 and A1:E7",
+			summary.SourceRows, summary.BlankRows, summary.FooterRows, summary.TableRange)
+	}
+}
+
+func TestReadSourceFileMergesDuplicates(t *testing.T) {
+	cfg := loadTestConfig(t)
+	svc := newTestService(t, cfg)
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC) }
+
+	file := excelize.NewFile()
+	defer file.Close()
+	rows := [][]interface{}{
+		{NAMA_ANAK, TANGGAL_LAHIR_ANAK, NAMA_ORANG_TUA, "Tanggal Imunisasi HB0", "Status Imunisasi HB0", "Tanggal Imunisasi BCG 1", "Status Imunisasi BCG 1"},
+		{"Muhammad Rizki", "2024-03-02", "Ibu Rina", "2024-03-02", "ideal", nil, "belum"},
+		{"Siti", "2024-03-02", "Ibu Rina", nil, "belum", nil, "belum"},
+		{"muhamad  rizky", "2024-03-02", "Ibu Rina", nil, "belum", "2024-04-01", "ideal"},
+		{"Muhammad Rizki", "2024-03-05", "Ibu Rina", nil, "belum", nil, "belum"},
+	}
+	for i, values := range rows {
+		file.SetSheetRow(SHEET_NAME, fmt.Sprintf("A%d", i+1), &values)
+	}
+
+	fileGeneration, err := svc.ReadSourceFile(XlsxSourceFile{
+		Ctx:       context.WithValue(context.Background(), sasaranTypeKey, BAYI),
+		SheetName: SHEET_NAME,
+		Reader:    NewXlsxReader(file),
+	})
+	if err != nil {
+		t.Fatalf("reading source file: %v", err)
+	}
+
+	// the fuzzy duplicate is merged into the first row, a different birth date is another child
+	list := fileGeneration.Generations[0].SasaranImunisasiList
+	if len(list) != 3 {
+		t.Fatalf("got %d children, want 3", len(list))
+	}
+	var merged SasaranImunisasi
+	for _, sasaranImunisasi := range list {
+		if sasaranImunisasi.SourceRow == 2 {
+			merged = sasaranImunisasi
+		}
+	}
+	if !merged.DetailImunisasi["HB0"].IsStatusIdeal() || !merged.DetailImunisasi["BCG 1"].IsStatusIdeal() {
+		t.Errorf("got detail imunisasi %v, want the ideal HB0 and BCG of both rows", merged.DetailImunisasi)
+	}
+
+	if len(fileGeneration.Duplicates) != 1 {
+		t.Fatalf("got duplicates %v, want row 4 only", fileGeneration.Duplicates)
+	}
+	duplicate := fileGeneration.Duplicates[0]
+	if duplicate.RowNumber != 4 || duplicate.MergedIntoRow != 2 || strings.Join(duplicate.AddedImunisasi, ",") != "BCG 1" {
+		t.Errorf("got duplicate %+v, want row 4 merged into row 2 adding BCG 1", duplicate)
+	}
+	if fileGeneration.Summary.RejectedByReason[REASON_DUPLICATE] != 1 {
+		t.Errorf("got rejected %v, want 1 duplicate", fileGeneration.Summary.RejectedByReason)
+	}
+}
@@ -1,15 +1,19 @@
 package sasaranimunisasi
 
 import (
+	"archive/zip"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
-
-	"github.com/xuri/excelize/v2"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // SasaranImunisasiHandler handles HTTP requests for generating Excel files.
@@ -25,10 +29,20 @@ func NewSasaranImunisasiHandler(svc XlsxFileTransformer) *SasaranImunisasiHandle
 }
 
 const (
-	maxUploadSize    = 10 << 20 // 10 MB
-	fileFormField    = "myFile"
-	sheetFormField   = "sheetName"
-	sasaranTypeField = "sasaranType"
+	maxUploadSize      = 10 << 20 // 10 MB
+	fileFormField      = "myFile"
+	sheetFormField     = "sheetName" // detected from the headers when empty or not found
+	sasaranTypeField   = "sasaranType"
+	tanggalAcuanField  = "tanggalAcuan"    // reference date in the format "YYYY-MM-DD", defaults to today
+	pisahPosyanduField = "pisahPosyandu"   // "sheet" or "zip" to split the list per posyandu, empty for a single list
+	statusField        = "statusImunisasi" // "label" or "angka" for the status columns, empty for the configured mode
+	layoutField        = "layout"          // "lengkap" or "ringkas" for the sasaran sheets and PDF, empty for the configured layout
+	formatField        = "format"          // "json", "pengingat", "undangan" or "pdf" to return the JSON document, reminder CSV, invitation PDF or sasaran PDF
+	formatJson         = "json"
+	formatPengingat    = "pengingat"
+	formatUndangan     = "undangan"
+	formatPdf          = "pdf"
+	contentTypeJson    = "application/json"
 )
 
 // GenerateFileHandler handles file uploads and generates a new Excel file.
@@ -39,6 +53,40 @@ func (h *SasaranImunisasiHandler) GenerateFileHandler(w http.ResponseWriter, r *
 		return
 	}
 
+	// Validate the request parameters carried through the context
+	sasaranType, err := ValidateSasaranType(r.FormValue(sasaranTypeField))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := context.WithValue(r.Context(), sasaranTypeKey, sasaranType)
+	if tanggalAcuan := r.FormValue(tanggalAcuanField); tanggalAcuan != EMPTY_STRING {
+		date, err := time.Parse("2006-01-02", tanggalAcuan)
+		if err != nil {
+			http.Error(w, "Invalid tanggalAcuan, expected format YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		ctx = context.WithValue(ctx, tanggalAcuanKey, date)
+	}
+	pisahPosyandu, err := ValidatePisahPosyandu(r.FormValue(pisahPosyanduField))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx = context.WithValue(ctx, pisahPosyanduKey, pisahPosyandu)
+	statusImunisasi, err := ValidateStatusImunisasi(r.FormValue(statusField))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx = context.WithValue(ctx, statusImunisasiKey, statusImunisasi)
+	layout, err := ValidateLayout(r.FormValue(layoutField))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx = context.WithValue(ctx, layoutKey, layout)
+
 	// Handle file upload
 	tempFilePath, err := HandleFileUpload(r)
 	if err != nil {
@@ -47,23 +95,85 @@ func (h *SasaranImunisasiHandler) GenerateFileHandler(w http.ResponseWriter, r *
 	}
 	defer os.Remove(tempFilePath)
 
-	// Retrieves the xlsx source file
-	ctx := context.WithValue(r.Context(), sasaranTypeKey, r.FormValue(sasaranTypeField))
+	// Retrieves the xlsx or CSV source file
 	sourceFile, err := GetXlsxSourceFile(tempFilePath, r.FormValue(sheetFormField), ctx)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	defer sourceFile.Reader.Close()
+
+	// Select the requested sheet, or detect it when it is omitted or wrong
+	if err := h.SasaranImunisasiService.SelectSourceSheet(sourceFile); err != nil {
+		WriteSourceErrorToResponse(w, err, "Error reading source file")
+		return
+	}
+
+	// Generate the reminder CSV when the client asks for the reminder messages
+	if strings.EqualFold(r.FormValue(formatField), formatPengingat) {
+		pengingatList, err := h.SasaranImunisasiService.GeneratePengingat(*sourceFile)
+		if err != nil {
+			WriteSourceErrorToResponse(w, err, "Error creating pengingat")
+			return
+		}
+		if err := WritePengingatCsvToResponse(w, pengingatList); err != nil {
+			http.Error(w, "Unable to generate pengingat", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Generate the print-ready PDF file when the client asks for it
+	if strings.EqualFold(r.FormValue(formatField), formatPdf) {
+		pdfFile, err := h.SasaranImunisasiService.GenerateSasaranPdf(*sourceFile)
+		if err != nil {
+			WriteSourceErrorToResponse(w, err, "Error creating PDF file")
+			return
+		}
+		if err := WritePdfFileToResponse(w, pdfFile); err != nil {
+			http.Error(w, "Unable to generate PDF file", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Generate the invitation slips when the client asks for them
+	if strings.EqualFold(r.FormValue(formatField), formatUndangan) {
+		pdfFile, err := h.SasaranImunisasiService.GenerateUndangan(*sourceFile)
+		if err != nil {
+			WriteSourceErrorToResponse(w, err, "Error creating undangan")
+			return
+		}
+		if err := WritePdfFileToResponse(w, pdfFile); err != nil {
+			http.Error(w, "Unable to generate undangan", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// Generate the JSON document when the client asks for JSON
+	if IsJsonRequested(r) {
+		document, err := h.SasaranImunisasiService.GenerateDocument(*sourceFile)
+		if err != nil {
+			WriteSourceErrorToResponse(w, err, "Error creating document")
+			return
+		}
+		if err := WriteJsonDocumentToResponse(w, document); err != nil {
+			http.Error(w, "Unable to generate document", http.StatusInternalServerError)
+		}
+		return
+	}
 
 	// Generate the new xlsx file
 	generatedFile, err := h.SasaranImunisasiService.GenerateFile(*sourceFile)
 	if err != nil {
-		http.Error(w, "Error creating file", http.StatusInternalServerError)
+		WriteSourceErrorToResponse(w, err, "Error creating file")
 		return
 	}
 
 	// Set response headers for file download
-	if err := WriteXlsxFileToResponse(w, generatedFile); err != nil {
+	writeToResponse := WriteXlsxFileToResponse
+	if len(generatedFile.PosyanduFiles) > 0 {
+		writeToResponse = WriteZipFileToResponse
+	}
+	if err := writeToResponse(w, generatedFile); err != nil {
 		http.Error(w, "Unable to generate file", http.StatusInternalServerError)
 		return
 	}
@@ -81,7 +191,7 @@ func HandleFileUpload(r *http.Request) (string, error) {
 	log.Printf("Uploaded File: %s, Size: %d, MIME: %v", fileHeader.Filename, fileHeader.Size, fileHeader.Header)
 
 	// Create a temporary file
-	tempFile, err := os.CreateTemp("temp", filepath.Base(fileHeader.Filename)+"-*.xlsx")
+	tempFile, err := os.CreateTemp("temp", filepath.Base(fileHeader.Filename)+"-*")
 	if err != nil {
 		log.Printf("Error creating temp file: %v", err)
 		return EMPTY_STRING, fmt.Errorf("failed to create temp file")
@@ -97,28 +207,44 @@ func HandleFileUpload(r *http.Request) (string, error) {
 	return tempFile.Name(), nil
 }
 
-// GetXlsxSourceFile returns source xlsx file from temp
+// GetXlsxSourceFile returns the xlsx or CSV source file from temp, the format is sniffed from its content.
+// The caller closes the reader of the returned source file.
 func GetXlsxSourceFile(tempFilePath, sheetName string, ctx context.Context) (*XlsxSourceFile, error) {
-	excelFile, err := excelize.OpenFile(tempFilePath)
+	reader, err := OpenSourceReader(tempFilePath)
 	if err != nil {
-		log.Printf("Error opening xlsx source file: %v", err)
-		return nil, fmt.Errorf("error opening xlsx source file")
+		log.Printf("Error opening source file: %v", err)
+		return nil, fmt.Errorf("error opening source file: %w", err)
+	}
+	if csvReader, ok := reader.(*CsvReader); ok {
+		log.Printf("Reading CSV source file, delimiter %q, encoding %s", csvReader.Delimiter, csvReader.Encoding)
 	}
-	defer excelFile.Close()
 
 	return &XlsxSourceFile{
 		Ctx:          ctx,
 		TempFilePath: tempFilePath,
 		SheetName:    sheetName,
-		ExcelizeFile: excelFile,
+		Reader:       reader,
 	}, nil
 }
 
+// WriteSourceErrorToResponse writes a 422 with the error when the source file has no usable sheet or lacks
+// required columns, and a 500 with the given message for any other error.
+func WriteSourceErrorToResponse(w http.ResponseWriter, err error, message string) {
+	var notFoundErr *SheetNotFoundError
+	var missingErr *MissingColumnsError
+	if errors.As(err, &notFoundErr) || errors.As(err, &missingErr) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	http.Error(w, message, http.StatusInternalServerError)
+}
+
 // WriteToResponse sets the headers and writes the generated Excel file to the response.
 func WriteXlsxFileToResponse(w http.ResponseWriter, generatedFile *XlsxGeneratedFile) error {
 	// Set response headers for file download
 	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
 	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, generatedFile.FileName))
+	SetSummaryHeaders(w, generatedFile.Summary)
 
 	// Write the generated Excel file to the response
 	if err := generatedFile.ExcelizeFile.Write(w); err != nil {
@@ -129,3 +255,115 @@ func WriteXlsxFileToResponse(w http.ResponseWriter, generatedFile *XlsxGenerated
 	log.Printf("Successfully uploaded and processed file: %s", generatedFile.FileName)
 	return nil
 }
+
+// IsJsonRequested reports whether the client asks for the JSON document, either with the format form field
+// or with an Accept header listing application/json.
+func IsJsonRequested(r *http.Request) bool {
+	if format := r.FormValue(formatField); format != EMPTY_STRING {
+		return strings.EqualFold(format, formatJson)
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(accept, ";")
+		if strings.EqualFold(strings.TrimSpace(mediaType), contentTypeJson) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteJsonDocumentToResponse sets the headers and writes the JSON document to the response.
+func WriteJsonDocumentToResponse(w http.ResponseWriter, document *SasaranImunisasiDocument) error {
+	content, err := json.Marshal(document)
+	if err != nil {
+		log.Printf("Error encoding JSON document: %v", err)
+		return fmt.Errorf("failed to encode JSON document: %w", err)
+	}
+
+	w.Header().Set("Content-Type", contentTypeJson+"; charset=utf-8")
+	SetSummaryHeaders(w, document.Summary)
+	if _, err := w.Write(content); err != nil {
+		log.Printf("Error writing JSON document to response: %v", err)
+		return fmt.Errorf("failed to write JSON document to response: %w", err)
+	}
+
+	log.Printf("Successfully uploaded and processed document: %s", document.Title)
+	return nil
+}
+
+// WritePengingatCsvToResponse sets the headers and writes the reminder messages as a CSV to the response.
+func WritePengingatCsvToResponse(w http.ResponseWriter, pengingatList []Pengingat) error {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, PENGINGAT_SHEET_NAME))
+	if err := WritePengingatCsv(w, pengingatList); err != nil {
+		log.Printf("Error writing pengingat to response: %v", err)
+		return fmt.Errorf("failed to write pengingat to response: %w", err)
+	}
+
+	log.Printf("Successfully uploaded and processed pengingat: %d messages", len(pengingatList))
+	return nil
+}
+
+// WritePdfFileToResponse sets the headers and writes the generated PDF file to the response.
+func WritePdfFileToResponse(w http.ResponseWriter, pdfFile *PdfGeneratedFile) error {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, pdfFile.FileName))
+	SetSummaryHeaders(w, pdfFile.Summary)
+
+	if err := pdfFile.Pdf.Output(w); err != nil {
+		log.Printf("Error writing generated PDF to response: %v", err)
+		return fmt.Errorf("failed to write PDF file to response: %w", err)
+	}
+
+	log.Printf("Successfully uploaded and processed file: %s", pdfFile.FileName)
+	return nil
+}
+
+// WriteZipFileToResponse sets the headers and writes a ZIP archive to the response containing the generated
+// Excel file followed by the Excel file of every posyandu.
+func WriteZipFileToResponse(w http.ResponseWriter, generatedFile *XlsxGeneratedFile) error {
+	zipFileName := generatedFile.GetZipFileName()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, zipFileName))
+	SetSummaryHeaders(w, generatedFile.Summary)
+
+	if err := WriteZipFile(w, generatedFile); err != nil {
+		return err
+	}
+
+	log.Printf("Successfully uploaded and processed file: %s", zipFileName)
+	return nil
+}
+
+// WriteZipFile writes a ZIP archive containing the generated Excel file followed by the Excel file of every posyandu.
+func WriteZipFile(w io.Writer, generatedFile *XlsxGeneratedFile) error {
+	zipWriter := zip.NewWriter(w)
+	for _, file := range append([]*XlsxGeneratedFile{generatedFile}, generatedFile.PosyanduFiles...) {
+		fileWriter, err := zipWriter.Create(file.FileName)
+		if err != nil {
+			log.Printf("Error adding %s to ZIP: %v", file.FileName, err)
+			return fmt.Errorf("failed to add %s to ZIP: %w", file.FileName, err)
+		}
+		if err := file.ExcelizeFile.Write(fileWriter); err != nil {
+			log.Printf("Error writing %s to ZIP: %v", file.FileName, err)
+			return fmt.Errorf("failed to write %s to ZIP: %w", file.FileName, err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		log.Printf("Error writing ZIP: %v", err)
+		return fmt.Errorf("failed to write ZIP: %w", err)
+	}
+	return nil
+}
+
+// GetZipFileName returns the name of the ZIP archive holding the generated file and the file of every posyandu.
+func (generatedFile *XlsxGeneratedFile) GetZipFileName() string {
+	return strings.TrimSuffix(generatedFile.FileName, ".xlsx") + ".zip"
+}
+
+// SetSummaryHeaders sets the row counts of the generation as response headers.
+func SetSummaryHeaders(w http.ResponseWriter, summary GenerationSummary) {
+	w.Header().Set("X-Sasaran-Source-Rows", strconv.Itoa(summary.SourceRows))
+	w.Header().Set("X-Sasaran-Rows", strconv.Itoa(summary.SasaranRows))
+	w.Header().Set("X-Sasaran-Out-Of-Area-Rows", strconv.Itoa(summary.OutOfAreaRows))
+	w.Header().Set("X-Sasaran-Blank-Rows", strconv.Itoa(summary.BlankRows))
+}
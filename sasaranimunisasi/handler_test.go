@@ -0,0 +1,241 @@
+package sasaranimunisasi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// loadTestConfig reads the sasaran imunisasi configuration from the repository config.yaml.
+func loadTestConfig(t testing.TB) *SasaranImunisasiConfig {
+	t.Helper()
+	content, err := os.ReadFile("../config.yaml")
+	if err != nil {
+		t.Fatalf("reading config: %v", err)
+	}
+
+	var cfg struct {
+		SasaranImunisasiCfg SasaranImunisasiConfig `yaml:"sasaran_imunisasi_config"`
+	}
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		t.Fatalf("decoding config: %v", err)
+	}
+	return &cfg.SasaranImunisasiCfg
+}
+
+// newTestService returns the service of the given config, failing the test when the config is invalid.
+func newTestService(t testing.TB, cfg *SasaranImunisasiConfig) *SasaranImunisasiService {
+	t.Helper()
+	svc, err := NewSasaranImunisasiService(cfg)
+	if err != nil {
+		t.Fatalf("creating service: %v", err)
+	}
+	return svc
+}
+
+// newSourceXlsx builds a source export whose header follows the given column map and whose
+// children are all named with the given prefix and have every immunization non-ideal.
+func newSourceXlsx(t testing.TB, columnMap map[string]Column, namePrefix string, rows int) []byte {
+	t.Helper()
+	file := excelize.NewFile()
+	defer file.Close()
+
+	colIndex := 1
+	for name := range columnMap {
+		if name == USIA_ANAK {
+			continue
+		}
+		label := GetXlsxColumnLabel(colIndex)
+		file.SetCellValue(SHEET_NAME, label+"1", name)
+		for row := 2; row < rows+2; row++ {
+			cell := fmt.Sprintf("%s%d", label, row)
+			switch {
+			case name == NAMA_ANAK:
+				file.SetCellValue(SHEET_NAME, cell, fmt.Sprintf("%s %d", namePrefix, row))
+			case name == TANGGAL_LAHIR_ANAK:
+				file.SetCellValue(SHEET_NAME, cell, fmt.Sprintf("2024-01-%02d", row%28+1))
+			case strings.Contains(name, STATUS):
+				file.SetCellValue(SHEET_NAME, cell, "belum")
+			default:
+				file.SetCellValue(SHEET_NAME, cell, namePrefix)
+			}
+		}
+		colIndex++
+	}
+
+	var buf bytes.Buffer
+	if err := file.Write(&buf); err != nil {
+		t.Fatalf("writing source xlsx: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newUploadRequest builds a multipart request the way the puskesmas upload form does.
+func newUploadRequest(t testing.TB, content []byte, sasaranType, tanggalAcuan string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(fileFormField, "export.xlsx")
+	if err != nil {
+		t.Fatalf("creating form file: %v", err)
+	}
+	part.Write(content)
+	writer.WriteField(sheetFormField, SHEET_NAME)
+	writer.WriteField(sasaranTypeField, sasaranType)
+	writer.WriteField(tanggalAcuanField, tanggalAcuan)
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/momworks/sasaran/imunisasi", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestGenerateFileHandlerConcurrentUploads(t *testing.T) {
+	if err := os.MkdirAll("temp", 0o755); err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll("temp") })
+
+	svc := newTestService(t, loadTestConfig(t))
+	handler := NewSasaranImunisasiHandler(svc)
+
+	const rows = 25
+	uploads := map[string]string{
+		BAYI:   "Bayi",
+		BADUTA: "Baduta",
+	}
+	sources := map[string][]byte{
+		BAYI:   newSourceXlsx(t, svc.SasaranBayiColumnMap, uploads[BAYI], rows),
+		BADUTA: newSourceXlsx(t, svc.SasaranBadutaColumnMap, uploads[BADUTA], rows),
+	}
+	// children are born in January 2024, so they are bayi in October 2024 and baduta in June 2025
+	tanggalAcuan := map[string]string{
+		BAYI:   "2024-10-01",
+		BADUTA: "2025-06-01",
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for sasaranType, namePrefix := range uploads {
+			req := newUploadRequest(t, sources[sasaranType], sasaranType, tanggalAcuan[sasaranType])
+			wg.Add(1)
+			go func(sasaranType, namePrefix string) {
+				defer wg.Done()
+
+				recorder := httptest.NewRecorder()
+				handler.GenerateFileHandler(recorder, req)
+				if recorder.Code != http.StatusOK {
+					t.Errorf("%s: unexpected status %d: %s", sasaranType, recorder.Code, recorder.Body.String())
+					return
+				}
+
+				generated, err := excelize.OpenReader(recorder.Body)
+				if err != nil {
+					t.Errorf("%s: opening generated file: %v", sasaranType, err)
+					return
+				}
+				defer generated.Close()
+
+				generatedRows, err := generated.GetRows(SHEET_NAME)
+				if err != nil {
+					t.Errorf("%s: reading generated rows: %v", sasaranType, err)
+					return
+				}
+
+				body := generatedRows[3:] // title, blank row and header come first
+				if len(body) != rows {
+					t.Errorf("%s: got %d children, want %d", sasaranType, len(body), rows)
+				}
+				for _, row := range body {
+					if !strings.HasPrefix(row[0], namePrefix+SPACE) {
+						t.Errorf("%s: found child %q from another upload", sasaranType, row[0])
+					}
+				}
+			}(sasaranType, namePrefix)
+		}
+	}
+	wg.Wait()
+}
+
+func TestGenerateFileHandlerRejectsUnknownSasaranType(t *testing.T) {
+	handler := NewSasaranImunisasiHandler(newTestService(t, loadTestConfig(t)))
+
+	recorder := httptest.NewRecorder()
+	handler.GenerateFileHandler(recorder, newUploadRequest(t, []byte("unused"), "balita", EMPTY_STRING))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("got status %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+	if body := recorder.Body.String(); !strings.Contains(body, "bayi, baduta, semua") {
+		t.Errorf("response does not list accepted values: %q", body)
+	}
+}
+
+func TestGenerateFileHandlerJsonDocument(t *testing.T) {
+	if err := os.MkdirAll("temp", 0o755); err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll("temp") })
+
+	svc := newTestService(t, loadTestConfig(t))
+	handler := NewSasaranImunisasiHandler(svc)
+	source := newSourceXlsx(t, svc.SasaranBayiColumnMap, "Bayi", 5)
+
+	req := newUploadRequest(t, source, BAYI, "2024-10-01")
+	req.Header.Set("Accept", "application/json")
+	recorder := httptest.NewRecorder()
+	handler.GenerateFileHandler(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		t.Errorf("got content type %q, want application/json", contentType)
+	}
+
+	var document SasaranImunisasiDocument
+	if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+		t.Fatalf("decoding document: %v", err)
+	}
+	if document.Version != DOCUMENT_VERSION || document.TanggalAcuan != "2024-10-01" {
+		t.Errorf("got version %d tanggal acuan %s", document.Version, document.TanggalAcuan)
+	}
+	if len(document.Sasaran) != 1 || document.Sasaran[0].Jumlah != 5 || document.Summary.SasaranRows != 5 {
+		t.Fatalf("got sasaran %+v, summary %+v", document.Sasaran, document.Summary)
+	}
+	detail := document.Sasaran[0].SasaranImunisasi[0].DetailImunisasi["HB0"]
+	if detail.Status["Status Imunisasi HB0"] != 1 {
+		t.Errorf("got detail imunisasi %+v, want non-ideal HB0 status", detail)
+	}
+}
+
+func TestGenerateFileHandlerRejectsUnknownSheet(t *testing.T) {
+	if err := os.MkdirAll("temp", 0o755); err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll("temp") })
+
+	file := excelize.NewFile()
+	file.SetSheetRow(SHEET_NAME, "A1", &[]string{"Nama", "Alamat"})
+	var source bytes.Buffer
+	file.Write(&source)
+	file.Close()
+
+	handler := NewSasaranImunisasiHandler(newTestService(t, loadTestConfig(t)))
+	recorder := httptest.NewRecorder()
+	handler.GenerateFileHandler(recorder, newUploadRequest(t, source.Bytes(), BAYI, "2024-10-01"))
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("got status %d, want %d", recorder.Code, http.StatusUnprocessableEntity)
+	}
+	if body := recorder.Body.String(); !strings.Contains(body, SHEET_NAME) || !strings.Contains(body, "Nama Anak, Tanggal Lahir Anak") {
+		t.Errorf("response does not list sheets and missing headers: %q", body)
+	}
+}
@@ -3,7 +3,6 @@ package sasaranimunisasi
 import (
 	"context"
 	"fmt"
-	"log"
 	"sort"
 	"strings"
 	"time"
@@ -11,19 +10,64 @@ import (
 
 // SasaranImunisasiConfig holds apps configuration for sasaran imunisasi
 type SasaranImunisasiConfig struct {
-	ColumnName             []string `yaml:"column_name"`
-	DetailImunisasi        []string `yaml:"detail_imunisasi"`
-	DetailImunisasiLengkap []string `yaml:"detail_imunisasi_lengkap"`
-	ImunisasiBayi          []string `yaml:"imunisasi_bayi"`
-	ImunisasiBaduta        []string `yaml:"imunisasi_baduta"`
+	ColumnName             []string            `yaml:"column_name"`
+	DetailImunisasi        []string            `yaml:"detail_imunisasi"`
+	DetailImunisasiLengkap []string            `yaml:"detail_imunisasi_lengkap"`
+	ImunisasiBayi          []string            `yaml:"imunisasi_bayi"`
+	ImunisasiBaduta        []string            `yaml:"imunisasi_baduta"`
+	JadwalImunisasi        []JadwalImunisasi   `yaml:"jadwal_imunisasi"`
+	Wilayah                WilayahConfig       `yaml:"wilayah"`
+	PosyanduColumn         string              `yaml:"posyandu_column"`  // source column grouping children per posyandu, defaults to the latest Pos Imunisasi
+	FooterKeywords         []string            `yaml:"footer_keywords"`  // words starting a total or footer row of the source export
+	ColumnAliases          map[string][]string `yaml:"column_aliases"`   // other source headers accepted for a column or detail_imunisasi prefix
+	RequiredColumns        []string            `yaml:"required_columns"` // source columns without which the request fails
+	DateLayouts            []string            `yaml:"date_layouts"`     // Go layouts of the source dates, tried in order after Excel serial numbers
+
+	DuplicateNameSimilarity float64          `yaml:"duplicate_name_similarity"` // minimum name similarity of duplicates, 0 to 1, names must be equal when unset
+	Watch                   WatchConfig      `yaml:"watch"`                     // folders of the watch-folder mode
+	Pengingat               PengingatConfig  `yaml:"pengingat"`                 // reminder messages sent to the parents
+	Undangan                UndanganConfig   `yaml:"undangan"`                  // invitation slips handed to the parents
+	Pdf                     PdfConfig        `yaml:"pdf"`                       // print-ready PDF file of the sasaran list
+	XlsxLayout              XlsxLayoutConfig `yaml:"xlsx_layout"`               // frozen panes, filter and print settings of the sasaran sheets
+	StatusImunisasi         string           `yaml:"status_imunisasi"`          // "label" or "angka", the mode of the status columns
+	HariSegera              int              `yaml:"hari_segera"`               // days ahead in which a not yet due antigen is colored as due soon, 0 to disable
+	Layout                  string           `yaml:"layout"`                    // "lengkap" or "ringkas", the layout of the sasaran sheets
+}
+
+// Compile compiles and validates every sub-config holding rules, templates or layouts, and the default modes of
+// the generated sheets. It is safe to call more than once.
+func (cfg *SasaranImunisasiConfig) Compile() error {
+	if err := cfg.Wilayah.Compile(); err != nil {
+		return fmt.Errorf("error validating wilayah config: %w", err)
+	}
+	if err := cfg.Pengingat.Compile(); err != nil {
+		return fmt.Errorf("error validating pengingat config: %w", err)
+	}
+	if err := cfg.Undangan.Compile(); err != nil {
+		return fmt.Errorf("error validating undangan config: %w", err)
+	}
+	if err := cfg.Pdf.Compile(); err != nil {
+		return fmt.Errorf("error validating pdf config: %w", err)
+	}
+	if err := cfg.XlsxLayout.Compile(); err != nil {
+		return fmt.Errorf("error validating xlsx_layout config: %w", err)
+	}
+	if _, err := ValidateStatusImunisasi(cfg.StatusImunisasi); err != nil {
+		return fmt.Errorf("error validating status_imunisasi config: %w", err)
+	}
+	if cfg.HariSegera < 0 {
+		return fmt.Errorf("error validating hari_segera config: invalid hari_segera %d, expected 0 or more days", cfg.HariSegera)
+	}
+	if _, err := ValidateLayout(cfg.Layout); err != nil {
+		return fmt.Errorf("error validating layout config: %w", err)
+	}
+	return nil
 }
 
 // SetColumnMap generates a map of column names to Column structures for the
 // given set of immunization data (imunisasi).
 func SetColumnMap(cfg *SasaranImunisasiConfig, imunisasi []string) map[string]Column {
 	baseColumns := cfg.ColumnName
-	detailImunisasi := cfg.DetailImunisasi
-	detailImunisasiLengkap := cfg.DetailImunisasiLengkap
 
 	columnMap := make(map[string]Column)
 	colIndex := 1
@@ -34,14 +78,16 @@ func SetColumnMap(cfg *SasaranImunisasiConfig, imunisasi []string) map[string]Co
 		colIndex++
 	}
 
+	// Add schedule columns computed from jadwal imunisasi
+	for _, columnName := range []string{IMUNISASI_BERIKUTNYA, TERLAMBAT} {
+		columnMap[columnName] = Column{Label: GetXlsxColumnLabel(colIndex)}
+		colIndex++
+	}
+
 	// Add immunization columns
 	for _, imun := range imunisasi {
-		details := detailImunisasi
-		if imun == IDL_1 || imun == IBL_1 {
-			details = detailImunisasiLengkap
-		}
-		for _, detail := range details {
-			columnMap[detail+SPACE+imun] = Column{Label: GetXlsxColumnLabel(colIndex)}
+		for _, columnName := range cfg.GetDetailColumnNames(imun) {
+			columnMap[columnName] = Column{Label: GetXlsxColumnLabel(colIndex)}
 			colIndex++
 		}
 	}
@@ -49,11 +95,44 @@ func SetColumnMap(cfg *SasaranImunisasiConfig, imunisasi []string) map[string]Co
 	return columnMap
 }
 
+// GetDetailImunisasi returns the configured details of an antigen, detail_imunisasi_lengkap for IDL 1 and IBL 1
+// and detail_imunisasi otherwise, e.g. "Tanggal Imunisasi".
+func (cfg *SasaranImunisasiConfig) GetDetailImunisasi(imun string) []string {
+	if imun == IDL_1 || imun == IBL_1 {
+		return cfg.DetailImunisasiLengkap
+	}
+	return cfg.DetailImunisasi
+}
+
+// GetDetailColumnNames returns the names of the detail columns of an antigen, e.g. "Tanggal Imunisasi HB0".
+func (cfg *SasaranImunisasiConfig) GetDetailColumnNames(imun string) []string {
+	columnNames := []string{}
+	for _, detail := range cfg.GetDetailImunisasi(imun) {
+		columnNames = append(columnNames, detail+SPACE+imun)
+	}
+	return columnNames
+}
+
+// IsComputedColumn reports whether the sasaran column is computed by the service instead of read from the source file.
+func IsComputedColumn(sasaranColumnName string) bool {
+	switch sasaranColumnName {
+	case USIA_ANAK, IMUNISASI_BERIKUTNYA, TERLAMBAT:
+		return true
+	}
+	return false
+}
+
 // Define a key type for context
 type contextKey string
 
-// Create a key for the sasaranType value
-const sasaranTypeKey contextKey = "sasaranType"
+// Create keys for the sasaranType, tanggalAcuan, pisahPosyandu, statusImunisasi and layout values
+const (
+	sasaranTypeKey     contextKey = "sasaranType"
+	tanggalAcuanKey    contextKey = "tanggalAcuan"
+	pisahPosyanduKey   contextKey = "pisahPosyandu"
+	statusImunisasiKey contextKey = "statusImunisasi"
+	layoutKey          contextKey = "layout"
+)
 
 // GetSasaranTypeFromContext retrieves sasaran type from context
 func GetSasaranTypeFromContext(ctx context.Context) string {
@@ -63,6 +142,36 @@ func GetSasaranTypeFromContext(ctx context.Context) string {
 	return EMPTY_STRING
 }
 
+// GetTanggalAcuanFromContext retrieves the reference date from context, it returns false if none was given
+func GetTanggalAcuanFromContext(ctx context.Context) (time.Time, bool) {
+	tanggalAcuan, ok := ctx.Value(tanggalAcuanKey).(time.Time)
+	return tanggalAcuan, ok
+}
+
+// GetPisahPosyanduFromContext retrieves the per-posyandu split mode from context, empty when the list is not split
+func GetPisahPosyanduFromContext(ctx context.Context) string {
+	if pisahPosyandu, ok := ctx.Value(pisahPosyanduKey).(string); ok {
+		return pisahPosyandu
+	}
+	return EMPTY_STRING
+}
+
+// GetStatusImunisasiFromContext retrieves the mode of the status columns from context, empty for the configured mode
+func GetStatusImunisasiFromContext(ctx context.Context) string {
+	if statusImunisasi, ok := ctx.Value(statusImunisasiKey).(string); ok {
+		return statusImunisasi
+	}
+	return EMPTY_STRING
+}
+
+// GetLayoutFromContext retrieves the layout of the sasaran sheets from context, empty for the configured layout
+func GetLayoutFromContext(ctx context.Context) string {
+	if layout, ok := ctx.Value(layoutKey).(string); ok {
+		return layout
+	}
+	return EMPTY_STRING
+}
+
 // common consts
 const (
 	EMPTY_STRING           = ""
@@ -70,6 +179,8 @@ const (
 	IDL_1                  = "IDL 1"
 	IBL_1                  = "IBL 1"
 	BAYI                   = "bayi"
+	BADUTA                 = "baduta"
+	SEMUA                  = "semua" // generates both bayi and baduta sheets from one upload
 	NAMA_ANAK              = "Nama Anak"
 	USIA_ANAK              = "Usia Anak"
 	TANGGAL_LAHIR_ANAK     = "Tanggal Lahir Anak"
@@ -83,32 +194,54 @@ const (
 	HYPHEN                 = "-"
 	STATUS_IDL_1           = "Status IDL 1"
 	STATUS_IMUNISASI_PCV_3 = "Status Imunisasi PCV 3"
+	IMUNISASI_BERIKUTNYA   = "Imunisasi Berikutnya"
+	TERLAMBAT              = "Terlambat"
 )
 
-// SortByStrDate sorts a list of generic items based on a string-formatted date extracted by the dateExtractor function.
-// The date format expected is "YYYY-MM-DD" (e.g., "2024-01-01"). If a date cannot be parsed, it logs an error and
-// keeps the current order for the erroneous item.
-func SortByStrDate[T any](list []T, dateExtractor func(T) string) {
-	sort.Slice(list, func(i, j int) bool {
-		dateFormat := "2006-01-02"
-		parseDate := func(index int) (time.Time, error) {
-			return time.Parse(dateFormat, dateExtractor(list[index]))
-		}
+// GetTanggalLengkapStr returns the given date in Indonesian with its weekday and year, e.g. "Kamis, 10 Oktober 2024".
+func GetTanggalLengkapStr(date time.Time) string {
+	days := map[time.Weekday]string{
+		time.Sunday:    "Minggu",
+		time.Monday:    "Senin",
+		time.Tuesday:   "Selasa",
+		time.Wednesday: "Rabu",
+		time.Thursday:  "Kamis",
+		time.Friday:    "Jumat",
+		time.Saturday:  "Sabtu",
+	}
 
-		dateI, errI := parseDate(i)
-		if errI != nil {
-			log.Printf("Error parsing date for index %d: %v", i, errI)
-			return false
-		}
+	return fmt.Sprintf("%s, %s %d", days[date.Weekday()], GetDateStr(date), date.Year())
+}
 
-		dateJ, errJ := parseDate(j)
-		if errJ != nil {
-			log.Printf("Error parsing date for index %d: %v", j, errJ)
-			return false
+// SortByDate sorts a list of generic items by the date extracted by the dateExtractor function, from the oldest
+// to the most recent. Items with the same date keep their source order so the generated file is reproducible.
+func SortByDate[T any](list []T, dateExtractor func(T) time.Time) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return dateExtractor(list[i]).Before(dateExtractor(list[j]))
+	})
+}
+
+// FormatValue returns the text of a value of a generated row as shown in the xlsx and PDF files, dates in the
+// DATE_FORMAT and "-" for no value.
+func FormatValue(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return HYPHEN
+	case time.Time:
+		if value.IsZero() {
+			return HYPHEN
 		}
+		return value.Format(DATE_FORMAT)
+	case string:
+		return value
+	}
+	return fmt.Sprint(value)
+}
 
-		return dateI.Before(dateJ)
-	})
+// NormalizeText returns the text in lower case with its words separated by a single space, so headers, names
+// and posyandu are matched case and whitespace insensitively.
+func NormalizeText(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), SPACE)
 }
 
 // CapitalizeFirstChar capitalizes the first character of a string.
@@ -119,8 +252,8 @@ func CapitalizeFirstChar(input string) string {
 	return strings.ToUpper(string(input[0])) + input[1:]
 }
 
-// GetCurrentDateStr returns the current Indonesian date as a formatted string in the format "Day Month" (e.g., "25 September").
-func GetCurrentDateStr() string {
+// GetDateStr returns the given date in Indonesian as a formatted string in the format "Day Month" (e.g., "25 September").
+func GetDateStr(currentDate time.Time) string {
 	months := map[time.Month]string{
 		time.January:   "Januari",
 		time.February:  "Februari",
@@ -136,6 +269,5 @@ func GetCurrentDateStr() string {
 		time.December:  "Desember",
 	}
 
-	currentDate := time.Now()
 	return fmt.Sprintf("%d %s", currentDate.Day(), months[currentDate.Month()])
 }
@@ -0,0 +1,96 @@
+package sasaranimunisasi
+
+// consts for the index sheet
+const (
+	INDEX_SHEET_NAME = "Indeks"
+)
+
+// IndexRow represents a row of the index sheet describing another sheet of the generated file.
+type IndexRow struct {
+	SheetName  string
+	Keterangan string
+	Jumlah     int
+}
+
+// IndexSheet generates the sheet listing the other sheets of the generated file with their row counts.
+// It implements NewXlsxGenerator.
+type IndexSheet struct {
+	Title string
+	Rows  []IndexRow
+}
+
+// indexHeader holds the header of the index sheet
+var indexHeader = []string{"Sheet", "Keterangan", "Jumlah Baris"}
+
+// NewIndexSheet returns the index sheet of the file generation listing the given sasaran sheets.
+func (fileGeneration *FileGeneration) NewIndexSheet(sasaranSheets []XlsxSheet) *IndexSheet {
+	indexSheet := &IndexSheet{
+		Title: GetFileName(fileGeneration.SasaranType, fileGeneration.TanggalAcuan),
+	}
+
+	for _, sheet := range sasaranSheets {
+		generation, ok := sheet.Generator.(*SasaranImunisasiGeneration)
+		if !ok {
+			continue
+		}
+		keterangan := "Sasaran imunisasi " + generation.SasaranType
+		if generation.Posyandu != EMPTY_STRING {
+			keterangan += ", " + generation.Posyandu
+		}
+		indexSheet.Rows = append(indexSheet.Rows, IndexRow{
+			SheetName:  sheet.Name,
+			Keterangan: keterangan,
+			Jumlah:     len(generation.SasaranImunisasiList),
+		})
+	}
+
+	isSingle := len(fileGeneration.Generations) == 1
+	for _, generation := range fileGeneration.Generations {
+		indexSheet.Rows = append(indexSheet.Rows, IndexRow{
+			SheetName:  generation.GetCakupanSheetName(isSingle),
+			Keterangan: "Rekap cakupan per antigen " + generation.SasaranType,
+			Jumlah:     len(generation.Cakupan),
+		})
+	}
+
+	if fileGeneration.Generations[0].Cfg.Pengingat.IsEnabled() {
+		indexSheet.Rows = append(indexSheet.Rows,
+			IndexRow{SheetName: PENGINGAT_SHEET_NAME, Keterangan: "Pesan pengingat untuk orang tua", Jumlah: len(fileGeneration.PengingatList)})
+	}
+
+	summary := fileGeneration.Summary
+	indexSheet.Rows = append(indexSheet.Rows,
+		IndexRow{SheetName: DUPLIKAT_SHEET_NAME, Keterangan: "Baris sumber digabung ke anak yang sama", Jumlah: len(fileGeneration.Duplicates)},
+		IndexRow{SheetName: REJECTED_SHEET_NAME, Keterangan: "Baris sumber yang dikecualikan", Jumlah: len(fileGeneration.RejectedRows)},
+		IndexRow{SheetName: HYPHEN, Keterangan: "Total baris sumber", Jumlah: summary.SourceRows},
+	)
+	if summary.BlankRows > 0 || summary.FooterRows > 0 {
+		indexSheet.Rows = append(indexSheet.Rows,
+			IndexRow{SheetName: HYPHEN, Keterangan: "Baris kosong dilewati", Jumlah: summary.BlankRows},
+			IndexRow{SheetName: HYPHEN, Keterangan: "Baris total atau catatan dilewati", Jumlah: summary.FooterRows},
+		)
+	}
+	return indexSheet
+}
+
+// SetTitle sets the title of the index sheet
+func (sheet *IndexSheet) SetTitle(newFile NewXlsxFile) {
+	newFile.SetTitleRow(sheet.Title, len(indexHeader))
+}
+
+// SetHeader sets the header row of the index sheet
+func (sheet *IndexSheet) SetHeader(newFile NewXlsxFile) {
+	newFile.SetRow(newFile.HeaderRowAt, ToInterfaces(indexHeader), newFile.HeaderStyle)
+}
+
+// SetBody sets the body rows of the index sheet
+func (sheet *IndexSheet) SetBody(newFile NewXlsxFile) {
+	for i, row := range sheet.Rows {
+		newFile.SetRow(i+newFile.StartBodyRowAt, []interface{}{row.SheetName, row.Keterangan, row.Jumlah}, newFile.BodyStyle)
+	}
+}
+
+// SetColumnWidth sets the column width of the index sheet
+func (sheet *IndexSheet) SetColumnWidth(newFile NewXlsxFile) {
+	newFile.StreamWriter.SetColWidth(1, len(indexHeader), 32)
+}
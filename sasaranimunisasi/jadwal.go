@@ -0,0 +1,145 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// JadwalImunisasi represents the recommended age window of an antigen. A dose is due
+// once the child reaches the Mulai age and becomes overdue after the Batas age.
+type JadwalImunisasi struct {
+	Imunisasi string     `yaml:"imunisasi"`
+	Mulai     UsiaJadwal `yaml:"mulai"`
+	Batas     UsiaJadwal `yaml:"batas"`
+}
+
+// UsiaJadwal represents an age expressed in months and days since birth.
+type UsiaJadwal struct {
+	Bulan int `yaml:"bulan"`
+	Hari  int `yaml:"hari"`
+}
+
+// StatusJadwal represents the schedule status of a single dose for a child.
+type StatusJadwal string
+
+// consts for schedule status
+const (
+	JADWAL_BELUM_WAKTUNYA StatusJadwal = "belum_waktunya" // the child has not reached the recommended age yet
+	JADWAL_JATUH_TEMPO    StatusJadwal = "jatuh_tempo"    // the dose should be given now
+	JADWAL_TERLAMBAT      StatusJadwal = "terlambat"      // the recommended age window has passed
+	JADWAL_SUDAH          StatusJadwal = "sudah"          // the dose has been given
+)
+
+// AddTo returns the date reached when the given date is added by this age.
+func (usia UsiaJadwal) AddTo(date time.Time) time.Time {
+	return date.AddDate(0, usia.Bulan, usia.Hari)
+}
+
+// GetJadwalImunisasi returns the schedule entries of the given antigens, ordered as configured in jadwal_imunisasi.
+func (cfg *SasaranImunisasiConfig) GetJadwalImunisasi(imunisasi []string) []JadwalImunisasi {
+	jadwalImunisasi := []JadwalImunisasi{}
+	for _, jadwal := range cfg.JadwalImunisasi {
+		for _, imun := range imunisasi {
+			if jadwal.Imunisasi == imun {
+				jadwalImunisasi = append(jadwalImunisasi, jadwal)
+				break
+			}
+		}
+	}
+	return jadwalImunisasi
+}
+
+// GetStatusJadwal returns the schedule status of a dose for a child born on birthDate as of refDate.
+func (jadwal JadwalImunisasi) GetStatusJadwal(birthDate, refDate time.Time, isGiven bool) StatusJadwal {
+	switch {
+	case isGiven:
+		return JADWAL_SUDAH
+	case refDate.Before(jadwal.Mulai.AddTo(birthDate)):
+		return JADWAL_BELUM_WAKTUNYA
+	case refDate.After(jadwal.Batas.AddTo(birthDate)):
+		return JADWAL_TERLAMBAT
+	default:
+		return JADWAL_JATUH_TEMPO
+	}
+}
+
+// IsGiven reports whether the immunization has been received, either because the source status
+// is ideal or because an immunization date has been recorded.
+func (detailImunisasi DetailImunisasi) IsGiven() bool {
+	for _, status := range detailImunisasi.Status {
+		if status == 0 {
+			return true
+		}
+	}
+	return len(detailImunisasi.Tanggal) > 0
+}
+
+// IsImunisasiNonIdeal reports whether an antigen of the child is non-ideal: due or overdue according to
+// jadwal imunisasi, or not given when the antigen has no schedule.
+func (sasaranImunisasi *SasaranImunisasi) IsImunisasiNonIdeal(imun string) bool {
+	if status, scheduled := sasaranImunisasi.StatusJadwal[imun]; scheduled {
+		return status == JADWAL_JATUH_TEMPO || status == JADWAL_TERLAMBAT
+	}
+	detailImunisasi, exists := sasaranImunisasi.DetailImunisasi[imun]
+	return !exists || !detailImunisasi.IsGiven()
+}
+
+// SetJadwalImunisasi computes the schedule status of every scheduled antigen as of refDate and fills
+// StatusJadwal, ImunisasiBerikutnya and ImunisasiTerlambat of the sasaran imunisasi.
+func (sasaranImunisasi *SasaranImunisasi) SetJadwalImunisasi(jadwalImunisasi []JadwalImunisasi, refDate time.Time) {
+	sasaranImunisasi.StatusJadwal = make(map[string]StatusJadwal)
+	sasaranImunisasi.ImunisasiBerikutnya = HYPHEN
+	sasaranImunisasi.ImunisasiTerlambat = []string{}
+
+	birthDate := sasaranImunisasi.TanggalLahirAnak
+	if birthDate.IsZero() {
+		return
+	}
+
+	dueList := []string{}
+	upcoming := EMPTY_STRING
+	for _, jadwal := range jadwalImunisasi {
+		detailImunisasi, exists := sasaranImunisasi.DetailImunisasi[jadwal.Imunisasi]
+		status := jadwal.GetStatusJadwal(birthDate, refDate, exists && detailImunisasi.IsGiven())
+		sasaranImunisasi.StatusJadwal[jadwal.Imunisasi] = status
+
+		switch status {
+		case JADWAL_TERLAMBAT:
+			sasaranImunisasi.ImunisasiTerlambat = append(sasaranImunisasi.ImunisasiTerlambat, jadwal.Imunisasi)
+			dueList = append(dueList, jadwal.Imunisasi)
+		case JADWAL_JATUH_TEMPO:
+			dueList = append(dueList, jadwal.Imunisasi)
+		case JADWAL_BELUM_WAKTUNYA:
+			if upcoming == EMPTY_STRING {
+				upcoming = fmt.Sprintf("%s (mulai %s)", jadwal.Imunisasi, jadwal.Mulai.AddTo(birthDate).Format(DATE_FORMAT))
+			}
+		}
+	}
+
+	switch {
+	case len(dueList) > 0:
+		sasaranImunisasi.ImunisasiBerikutnya = strings.Join(dueList, ", ")
+	case upcoming != EMPTY_STRING:
+		sasaranImunisasi.ImunisasiBerikutnya = upcoming
+	}
+}
+
+// GetTerlambatStr returns the overdue antigens as a comma separated string, or "-" if there is none.
+func (sasaranImunisasi *SasaranImunisasi) GetTerlambatStr() string {
+	if len(sasaranImunisasi.ImunisasiTerlambat) == 0 {
+		return HYPHEN
+	}
+	return strings.Join(sasaranImunisasi.ImunisasiTerlambat, ", ")
+}
+
+// GetImunisasiBelum returns the non-ideal antigens among the given ones, in the given order, see IsImunisasiNonIdeal.
+func (sasaranImunisasi *SasaranImunisasi) GetImunisasiBelum(imunisasi []string) []string {
+	imunisasiBelum := []string{}
+	for _, imun := range imunisasi {
+		if sasaranImunisasi.IsImunisasiNonIdeal(imun) {
+			imunisasiBelum = append(imunisasiBelum, imun)
+		}
+	}
+	return imunisasiBelum
+}
@@ -0,0 +1,113 @@
+package sasaranimunisasi
+
+import (
+	"testing"
+	"time"
+)
+
+// date returns the given day at midnight UTC, for the schedule and age tests.
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestGetStatusJadwal(t *testing.T) {
+	hb0 := JadwalImunisasi{Imunisasi: "HB0", Mulai: UsiaJadwal{Hari: 0}, Batas: UsiaJadwal{Hari: 7}}
+	dpt1 := JadwalImunisasi{Imunisasi: "DPT-Hb-Hib 1", Mulai: UsiaJadwal{Bulan: 2}, Batas: UsiaJadwal{Bulan: 3}}
+	birthDate := date(2024, time.January, 10)
+
+	tests := []struct {
+		name    string
+		jadwal  JadwalImunisasi
+		refDate time.Time
+		isGiven bool
+		want    StatusJadwal
+	}{
+		{"day window on the birth date", hb0, birthDate, false, JADWAL_JATUH_TEMPO},
+		{"day window on batas", hb0, date(2024, time.January, 17), false, JADWAL_JATUH_TEMPO},
+		{"day window the day after batas", hb0, date(2024, time.January, 18), false, JADWAL_TERLAMBAT},
+		{"month window the day before mulai", dpt1, date(2024, time.March, 9), false, JADWAL_BELUM_WAKTUNYA},
+		{"month window on mulai", dpt1, date(2024, time.March, 10), false, JADWAL_JATUH_TEMPO},
+		{"month window on batas", dpt1, date(2024, time.April, 10), false, JADWAL_JATUH_TEMPO},
+		{"month window the day after batas", dpt1, date(2024, time.April, 11), false, JADWAL_TERLAMBAT},
+		{"given before mulai", dpt1, date(2024, time.February, 1), true, JADWAL_SUDAH},
+		{"given after batas", dpt1, date(2024, time.December, 1), true, JADWAL_SUDAH},
+	}
+	for _, test := range tests {
+		if got := test.jadwal.GetStatusJadwal(birthDate, test.refDate, test.isGiven); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	// months are added to the calendar date, so a window from the end of January starts in early March
+	if got := dpt1.GetStatusJadwal(date(2023, time.December, 31), date(2024, time.February, 29), false); got != JADWAL_BELUM_WAKTUNYA {
+		t.Errorf("month-end birth date: got %q before mulai, want %q", got, JADWAL_BELUM_WAKTUNYA)
+	}
+	if got := dpt1.GetStatusJadwal(date(2023, time.December, 31), date(2024, time.March, 2), false); got != JADWAL_JATUH_TEMPO {
+		t.Errorf("month-end birth date: got %q on mulai, want %q", got, JADWAL_JATUH_TEMPO)
+	}
+}
+
+func TestIsGiven(t *testing.T) {
+	tests := []struct {
+		name            string
+		detailImunisasi DetailImunisasi
+		want            bool
+	}{
+		{"ideal status", DetailImunisasi{Status: map[string]int{"Status Imunisasi HB0": 0}}, true},
+		{"non-ideal status", DetailImunisasi{Status: map[string]int{"Status Imunisasi HB0": 1}}, false},
+		{"date without status", DetailImunisasi{Tanggal: map[string]time.Time{"Tanggal Imunisasi HB0": date(2024, time.January, 10)}}, true},
+		{"date with non-ideal status", DetailImunisasi{
+			Tanggal: map[string]time.Time{"Tanggal Imunisasi HB0": date(2024, time.January, 10)},
+			Status:  map[string]int{"Status Imunisasi HB0": 1},
+		}, true},
+		{"no data", DetailImunisasi{}, false},
+	}
+	for _, test := range tests {
+		if got := test.detailImunisasi.IsGiven(); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSetJadwalImunisasi(t *testing.T) {
+	jadwalImunisasi := []JadwalImunisasi{
+		{Imunisasi: "HB0", Mulai: UsiaJadwal{Hari: 0}, Batas: UsiaJadwal{Hari: 7}},
+		{Imunisasi: "BCG 1", Mulai: UsiaJadwal{Bulan: 0}, Batas: UsiaJadwal{Bulan: 1}},
+		{Imunisasi: "DPT-Hb-Hib 1", Mulai: UsiaJadwal{Bulan: 2}, Batas: UsiaJadwal{Bulan: 3}},
+		{Imunisasi: "MR 1", Mulai: UsiaJadwal{Bulan: 9}, Batas: UsiaJadwal{Bulan: 10}},
+	}
+	given := DetailImunisasi{Status: map[string]int{STATUS: 0}}
+
+	tests := []struct {
+		name           string
+		given          []string
+		refDate        time.Time
+		wantBerikutnya string
+		wantTerlambat  string
+	}{
+		{"overdue and due now", []string{"HB0"}, date(2024, time.March, 15), "BCG 1, DPT-Hb-Hib 1", "BCG 1"},
+		{"every due dose given", []string{"HB0", "BCG 1"}, date(2024, time.February, 1), "DPT-Hb-Hib 1 (mulai 10-03-2024)", HYPHEN},
+		{"every dose given", []string{"HB0", "BCG 1", "DPT-Hb-Hib 1", "MR 1"}, date(2024, time.December, 1), HYPHEN, HYPHEN},
+	}
+	for _, test := range tests {
+		sasaranImunisasi := SasaranImunisasi{TanggalLahirAnak: date(2024, time.January, 10), DetailImunisasi: map[string]DetailImunisasi{}}
+		for _, imun := range test.given {
+			sasaranImunisasi.DetailImunisasi[imun] = given
+		}
+		sasaranImunisasi.SetJadwalImunisasi(jadwalImunisasi, test.refDate)
+
+		if sasaranImunisasi.ImunisasiBerikutnya != test.wantBerikutnya {
+			t.Errorf("%s: got imunisasi berikutnya %q, want %q", test.name, sasaranImunisasi.ImunisasiBerikutnya, test.wantBerikutnya)
+		}
+		if got := sasaranImunisasi.GetTerlambatStr(); got != test.wantTerlambat {
+			t.Errorf("%s: got terlambat %q, want %q", test.name, got, test.wantTerlambat)
+		}
+	}
+
+	// a child without a birth date has no schedule
+	sasaranImunisasi := SasaranImunisasi{}
+	sasaranImunisasi.SetJadwalImunisasi(jadwalImunisasi, date(2024, time.March, 15))
+	if sasaranImunisasi.ImunisasiBerikutnya != HYPHEN || len(sasaranImunisasi.StatusJadwal) != 0 {
+		t.Errorf("got imunisasi berikutnya %q and status %v without a birth date", sasaranImunisasi.ImunisasiBerikutnya, sasaranImunisasi.StatusJadwal)
+	}
+}
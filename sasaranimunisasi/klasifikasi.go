@@ -0,0 +1,67 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// consts for classifying sasaran by age in completed months
+const (
+	USIA_MAKS_BAYI_BULAN   = 11
+	USIA_MAKS_BADUTA_BULAN = 23
+)
+
+// AcceptedSasaranTypes holds the sasaran type values accepted by the sasaranType form field
+var AcceptedSasaranTypes = []string{BAYI, BADUTA, SEMUA}
+
+// ValidateSasaranType normalizes the requested sasaran type and returns an error listing the accepted values
+// when it is unknown. An empty sasaran type means every child is classified automatically, like "semua".
+func ValidateSasaranType(sasaranType string) (string, error) {
+	sasaranType = strings.ToLower(strings.TrimSpace(sasaranType))
+	if sasaranType == EMPTY_STRING {
+		return SEMUA, nil
+	}
+
+	for _, accepted := range AcceptedSasaranTypes {
+		if sasaranType == accepted {
+			return sasaranType, nil
+		}
+	}
+	return EMPTY_STRING, fmt.Errorf("invalid sasaranType %q, accepted values: %s", sasaranType, strings.Join(AcceptedSasaranTypes, ", "))
+}
+
+// GetUsiaBulan returns the age in completed months at the reference date of a child born on birthDate.
+func GetUsiaBulan(birthDate, tanggalAcuan time.Time) int {
+	months := tanggalAcuan.Year()*12 + int(tanggalAcuan.Month()) - (birthDate.Year()*12 + int(birthDate.Month()))
+	if tanggalAcuan.Day() < birthDate.Day() {
+		months--
+	}
+	return months
+}
+
+// ClassifySasaranType returns the sasaran type of a child from their age at the reference date: 0-11 months
+// is bayi and 12-23 months is baduta. It returns an empty string for children outside both age ranges.
+func ClassifySasaranType(birthDate, tanggalAcuan time.Time) string {
+	usiaBulan := GetUsiaBulan(birthDate, tanggalAcuan)
+	switch {
+	case usiaBulan < 0 || birthDate.After(tanggalAcuan):
+		return EMPTY_STRING
+	case usiaBulan <= USIA_MAKS_BAYI_BULAN:
+		return BAYI
+	case usiaBulan <= USIA_MAKS_BADUTA_BULAN:
+		return BADUTA
+	default:
+		return EMPTY_STRING
+	}
+}
+
+// GetGeneration returns the generation of the given sasaran type, or nil when the file does not generate it.
+func (fileGeneration *FileGeneration) GetGeneration(sasaranType string) *SasaranImunisasiGeneration {
+	for _, generation := range fileGeneration.Generations {
+		if generation.SasaranType == sasaranType {
+			return generation
+		}
+	}
+	return nil
+}
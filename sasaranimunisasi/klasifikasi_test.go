@@ -0,0 +1,38 @@
+package sasaranimunisasi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClassifySasaranType(t *testing.T) {
+	tests := []struct {
+		name         string
+		birthDate    time.Time
+		tanggalAcuan time.Time
+		wantUsia     int
+		want         string
+	}{
+		{"born on the reference date", date(2024, time.October, 3), date(2024, time.October, 3), 0, BAYI},
+		{"born after the reference date", date(2024, time.October, 4), date(2024, time.October, 3), -1, EMPTY_STRING},
+		{"day before 12 months", date(2023, time.October, 3), date(2024, time.October, 2), 11, BAYI},
+		{"12 months", date(2023, time.October, 3), date(2024, time.October, 3), 12, BADUTA},
+		{"day before 24 months", date(2022, time.October, 3), date(2024, time.October, 2), 23, BADUTA},
+		{"24 months", date(2022, time.October, 3), date(2024, time.October, 3), 24, EMPTY_STRING},
+		{"month-end birth date before 12 months", date(2023, time.January, 31), date(2024, time.January, 30), 11, BAYI},
+		{"month-end birth date at 12 months", date(2023, time.January, 31), date(2024, time.January, 31), 12, BADUTA},
+		{"month-end birth date in a shorter month", date(2023, time.March, 31), date(2024, time.February, 29), 10, BAYI},
+		{"leap day birth date before 12 months", date(2020, time.February, 29), date(2021, time.February, 28), 11, BAYI},
+		{"leap day birth date at 12 months", date(2020, time.February, 29), date(2021, time.March, 1), 12, BADUTA},
+		{"leap day birth date before 24 months", date(2020, time.February, 29), date(2022, time.February, 28), 23, BADUTA},
+		{"leap day birth date at 24 months", date(2020, time.February, 29), date(2022, time.March, 1), 24, EMPTY_STRING},
+	}
+	for _, test := range tests {
+		if got := GetUsiaBulan(test.birthDate, test.tanggalAcuan); got != test.wantUsia {
+			t.Errorf("%s: GetUsiaBulan = %d, want %d", test.name, got, test.wantUsia)
+		}
+		if got := ClassifySasaranType(test.birthDate, test.tanggalAcuan); got != test.want {
+			t.Errorf("%s: ClassifySasaranType = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
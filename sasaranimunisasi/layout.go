@@ -0,0 +1,192 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// consts for the layout of the table sheets
+const (
+	XLSX_MIN_COLUMN_WIDTH = 8
+	XLSX_MAX_COLUMN_WIDTH = 50
+	XLSX_COLUMN_WIDTH     = 32   // fixed column width when the widths are not fitted to the content
+	XLSX_CHAR_WIDTH       = 1.2  // column width of a character of the 12pt body font
+	XLSX_LINE_HEIGHT      = 16.0 // row height of a line of the 12pt body font, in points
+	XLSX_PAPER_A4         = 9
+)
+
+// XlsxLayoutConfig holds the layout of the sasaran sheets of the generated file, for scrolling, filtering
+// and printing the list.
+type XlsxLayoutConfig struct {
+	FreezeHeader   bool    `yaml:"freeze_header"`  // keep the title and header rows visible when scrolling
+	FreezeColumns  int     `yaml:"freeze_columns"` // leading columns kept visible when scrolling, e.g. 1 for Nama Anak
+	AutoFilter     bool    `yaml:"autofilter"`     // filter buttons on the header row
+	Landscape      bool    `yaml:"landscape"`
+	FitToWidth     bool    `yaml:"fit_to_width"`  // print every column on the width of the page
+	RepeatHeader   bool    `yaml:"repeat_header"` // print the header row on every page
+	AutoWidth      bool    `yaml:"auto_width"`    // fit every column to its longest value instead of the fixed width
+	MinColumnWidth float64 `yaml:"min_column_width"`
+	MaxColumnWidth float64 `yaml:"max_column_width"`
+}
+
+// XlsxTableGenerator is implemented by the generators whose sheet is a table laid out for scrolling,
+// filtering and printing. AddXlsxSheet applies the layout before the generator streams the sheet.
+type XlsxTableGenerator interface {
+	GetXlsxLayout() XlsxLayout
+}
+
+// XlsxLayout holds the layout of a table sheet together with its size.
+type XlsxLayout struct {
+	Cfg         *XlsxLayoutConfig
+	ColumnCount int
+	RowCount    int // number of body rows
+}
+
+// Compile fills the default column widths and validates them. It is safe to call more than once.
+func (cfg *XlsxLayoutConfig) Compile() error {
+	if cfg.MinColumnWidth == 0 {
+		cfg.MinColumnWidth = XLSX_MIN_COLUMN_WIDTH
+	}
+	if cfg.MaxColumnWidth == 0 {
+		cfg.MaxColumnWidth = XLSX_MAX_COLUMN_WIDTH
+	}
+	if cfg.MinColumnWidth < 0 || cfg.MaxColumnWidth < cfg.MinColumnWidth {
+		return fmt.Errorf("invalid xlsx_layout column widths %v to %v", cfg.MinColumnWidth, cfg.MaxColumnWidth)
+	}
+	if cfg.FreezeColumns < 0 {
+		return fmt.Errorf("invalid xlsx_layout freeze_columns %d", cfg.FreezeColumns)
+	}
+	return nil
+}
+
+// ApplyXlsxLayout sets the frozen panes, the autofilter and the print settings of the table sheet. It is called
+// before any row is streamed.
+func (newFile NewXlsxFile) ApplyXlsxLayout(layout XlsxLayout) error {
+	cfg := layout.Cfg
+	if err := newFile.StreamWriter.SetPanes(GetXlsxPanes(cfg, newFile.HeaderRowAt, layout.ColumnCount)); err != nil {
+		return err
+	}
+
+	headerRow := strconv.Itoa(newFile.HeaderRowAt)
+	lastColumn := GetXlsxColumnLabel(layout.ColumnCount)
+	if cfg.AutoFilter && layout.ColumnCount > 0 {
+		lastRow := strconv.Itoa(newFile.HeaderRowAt + max(layout.RowCount, 1))
+		if err := newFile.ExcelizeFile.AutoFilter(newFile.SheetName, A+headerRow+":"+lastColumn+lastRow, nil); err != nil {
+			return err
+		}
+		// excelize names the filter range _xlnm.Criteria instead of _xlnm._FilterDatabase; the autoFilter element
+		// of the sheet is enough for Excel, so the misnamed range is dropped rather than left for advanced filters
+		if err := newFile.ExcelizeFile.DeleteDefinedName(&excelize.DefinedName{Name: "_xlnm.Criteria", Scope: newFile.SheetName}); err != nil {
+			return err
+		}
+	}
+
+	pageLayout := excelize.PageLayoutOptions{Size: intPointer(XLSX_PAPER_A4)}
+	if cfg.Landscape {
+		orientation := "landscape"
+		pageLayout.Orientation = &orientation
+	}
+	if cfg.FitToWidth {
+		// a zero height lets the rows run over as many pages as needed
+		pageLayout.FitToWidth, pageLayout.FitToHeight = intPointer(1), intPointer(0)
+		fitToPage := true
+		if err := newFile.ExcelizeFile.SetSheetProps(newFile.SheetName, &excelize.SheetPropsOptions{FitToPage: &fitToPage}); err != nil {
+			return err
+		}
+	}
+	if err := newFile.ExcelizeFile.SetPageLayout(newFile.SheetName, &pageLayout); err != nil {
+		return err
+	}
+
+	if cfg.RepeatHeader {
+		return newFile.ExcelizeFile.SetDefinedName(&excelize.DefinedName{
+			Name:     "_xlnm.Print_Titles",
+			RefersTo: fmt.Sprintf("'%s'!$%s:$%s", strings.ReplaceAll(newFile.SheetName, "'", "''"), headerRow, headerRow),
+			Scope:    newFile.SheetName,
+		})
+	}
+	return nil
+}
+
+// GetXlsxPanes returns the panes freezing the rows up to the header row and the configured leading columns,
+// or no frozen pane when nothing is frozen.
+func GetXlsxPanes(cfg *XlsxLayoutConfig, headerRowAt, columnCount int) *excelize.Panes {
+	xSplit, ySplit := min(cfg.FreezeColumns, columnCount), 0
+	if cfg.FreezeHeader {
+		ySplit = headerRowAt
+	}
+
+	panes := &excelize.Panes{Freeze: xSplit > 0 || ySplit > 0, XSplit: xSplit, YSplit: ySplit}
+	switch {
+	case xSplit > 0 && ySplit > 0:
+		panes.ActivePane = "bottomRight"
+	case ySplit > 0:
+		panes.ActivePane = "bottomLeft"
+	case xSplit > 0:
+		panes.ActivePane = "topRight"
+	default:
+		return panes
+	}
+	panes.TopLeftCell = GetXlsxColumnLabel(xSplit+1) + strconv.Itoa(ySplit+1)
+	return panes
+}
+
+// GetColumnWidths returns the width of every column fitting its longest value and the longest word of its
+// header, which wraps, within the configured minimum and maximum width. Longer values overflow.
+func (cfg *XlsxLayoutConfig) GetColumnWidths(header []string, rows [][]interface{}) []float64 {
+	chars := make([]int, len(header))
+	for i, name := range header {
+		for _, word := range strings.Fields(name) {
+			chars[i] = max(chars[i], utf8.RuneCountInString(word))
+		}
+	}
+	for _, row := range rows {
+		for i, value := range row {
+			chars[i] = max(chars[i], utf8.RuneCountInString(FormatValue(value)))
+		}
+	}
+
+	widths := make([]float64, len(header))
+	for i := range widths {
+		widths[i] = min(max(float64(chars[i])*XLSX_CHAR_WIDTH+2, cfg.MinColumnWidth), cfg.MaxColumnWidth)
+	}
+	return widths
+}
+
+// GetWrappedLineCount returns the number of lines of the text wrapped at its words to the given column width.
+func GetWrappedLineCount(text string, width float64) int {
+	lineChars := max(int(width/XLSX_CHAR_WIDTH), 1)
+	lines, length := 1, 0
+	for _, word := range strings.Fields(text) {
+		wordLength := utf8.RuneCountInString(word)
+		switch {
+		case length == 0:
+			length = wordLength
+		case length+1+wordLength > lineChars:
+			lines++
+			length = wordLength
+		default:
+			length += 1 + wordLength
+		}
+	}
+	return lines
+}
+
+// SetColumnWidths sets the width of every column from the first one.
+func (newFile NewXlsxFile) SetColumnWidths(widths []float64) {
+	for i, width := range widths {
+		if err := newFile.StreamWriter.SetColWidth(i+1, i+1, width); err != nil {
+			log.Printf("Error setting column width of sheet %s: %v", newFile.SheetName, err)
+		}
+	}
+}
+
+// intPointer returns a pointer to the given int, for the optional fields of the excelize options.
+func intPointer(value int) *int {
+	return &value
+}
@@ -0,0 +1,127 @@
+package sasaranimunisasi
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestGetXlsxPanes(t *testing.T) {
+	tests := []struct {
+		cfg  XlsxLayoutConfig
+		want excelize.Panes
+	}{
+		{XlsxLayoutConfig{FreezeHeader: true, FreezeColumns: 1}, excelize.Panes{Freeze: true, XSplit: 1, YSplit: 3, TopLeftCell: "B4", ActivePane: "bottomRight"}},
+		{XlsxLayoutConfig{FreezeHeader: true}, excelize.Panes{Freeze: true, YSplit: 3, TopLeftCell: "A4", ActivePane: "bottomLeft"}},
+		{XlsxLayoutConfig{FreezeColumns: 30}, excelize.Panes{Freeze: true, XSplit: 20, TopLeftCell: "U1", ActivePane: "topRight"}},
+		{XlsxLayoutConfig{}, excelize.Panes{}},
+	}
+	for _, test := range tests {
+		got := GetXlsxPanes(&test.cfg, 3, 20)
+		if got.Freeze != test.want.Freeze || got.XSplit != test.want.XSplit || got.YSplit != test.want.YSplit ||
+			got.TopLeftCell != test.want.TopLeftCell || got.ActivePane != test.want.ActivePane {
+			t.Errorf("GetXlsxPanes(%+v) = %+v, want %+v", test.cfg, *got, test.want)
+		}
+	}
+}
+
+func TestGetWrappedLineCount(t *testing.T) {
+	tests := []struct {
+		text  string
+		width float64
+		want  int
+	}{
+		{"Status Imunisasi DPT-Hb-Hib 1", 40, 1},
+		{"Status Imunisasi DPT-Hb-Hib 1", 20, 2},
+		{"Status Imunisasi DPT-Hb-Hib 1", 12, 4},
+		{EMPTY_STRING, 10, 1},
+	}
+	for _, test := range tests {
+		if got := GetWrappedLineCount(test.text, test.width); got != test.want {
+			t.Errorf("GetWrappedLineCount(%q, %v) = %d, want %d", test.text, test.width, got, test.want)
+		}
+	}
+}
+
+func TestGenerateFileXlsxLayout(t *testing.T) {
+	cfg := loadTestConfig(t)
+	svc := newTestService(t, cfg)
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC) }
+
+	sourceFile := newGoldenSourceXlsx(t, cfg, cfg.ImunisasiBayi)
+	defer sourceFile.Reader.Close()
+	sourceFile.Ctx = context.WithValue(context.Background(), sasaranTypeKey, BAYI)
+	generatedFile, err := svc.GenerateFile(sourceFile)
+	if err != nil {
+		t.Fatalf("generating file: %v", err)
+	}
+
+	// read the saved file back, as the layout is written around the streamed rows
+	var buf bytes.Buffer
+	if err := generatedFile.ExcelizeFile.Write(&buf); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+	file, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("opening file: %v", err)
+	}
+	defer file.Close()
+
+	panes, err := file.GetPanes(SHEET_NAME)
+	if err != nil || !panes.Freeze || panes.XSplit != 1 || panes.YSplit != 3 {
+		t.Errorf("got panes %+v and error %v, want the header rows and Nama Anak frozen", panes, err)
+	}
+	pageLayout, err := file.GetPageLayout(SHEET_NAME)
+	if err != nil || *pageLayout.Orientation != "landscape" || *pageLayout.FitToWidth != 1 || *pageLayout.FitToHeight != 0 {
+		t.Errorf("got page layout %+v and error %v, want landscape fitted to the width", pageLayout, err)
+	}
+
+	definedNames := map[string]string{}
+	for _, definedName := range file.GetDefinedName() {
+		if definedName.Scope == SHEET_NAME {
+			definedNames[definedName.Name] = definedName.RefersTo
+		}
+	}
+	if got := definedNames["_xlnm.Print_Titles"]; got != "'Sheet1'!$3:$3" {
+		t.Errorf("got print titles %q, want the header row", got)
+	}
+	if len(definedNames) != 1 {
+		t.Errorf("got defined names %v, want only the print titles", definedNames)
+	}
+	if !bytes.Contains(sheetXml(t, file), []byte(`<autoFilter ref="$A$3:$BJ$7"`)) {
+		t.Errorf("got no autofilter on the header and body rows")
+	}
+
+	// Nama Anak fits its longest value, the status columns their header word
+	if width, _ := file.GetColWidth(SHEET_NAME, "A"); width != cfg.XlsxLayout.MinColumnWidth && width >= XLSX_COLUMN_WIDTH {
+		t.Errorf("got Nama Anak width %v, want fitted to the names", width)
+	}
+}
+
+// sheetXml returns the XML of the first sheet of the saved file.
+func sheetXml(t *testing.T, file *excelize.File) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := file.Write(&buf); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("reading file: %v", err)
+	}
+	sheet, err := reader.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatalf("opening sheet: %v", err)
+	}
+	defer sheet.Close()
+	content, err := io.ReadAll(sheet)
+	if err != nil {
+		t.Fatalf("reading sheet: %v", err)
+	}
+	return content
+}
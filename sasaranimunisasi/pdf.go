@@ -0,0 +1,228 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// consts for the generated PDF files
+const (
+	PDF_FONT        = "Helvetica" // core font, so no font file is embedded
+	PDF_MARGIN      = 10          // page margin in mm
+	PDF_LINE_HEIGHT = 5           // height of a line of body text in mm
+)
+
+// PdfGeneratedFile holds a generated PDF file with its filename and the row counts of the generation.
+type PdfGeneratedFile struct {
+	FileName string
+	Pdf      *fpdf.Fpdf
+	Summary  GenerationSummary
+}
+
+// PdfWriter wraps an A4 fpdf document, translating the UTF-8 text of the source file to the encoding of
+// the core fonts.
+type PdfWriter struct {
+	Pdf       *fpdf.Fpdf
+	translate func(string) string
+}
+
+// NewPdfWriter returns a PdfWriter of an A4 document in the given orientation, "P" or "L", with the given title.
+func NewPdfWriter(orientation, title string) *PdfWriter {
+	pdf := fpdf.New(orientation, "mm", "A4", EMPTY_STRING)
+	pdf.SetMargins(PDF_MARGIN, PDF_MARGIN, PDF_MARGIN)
+	pdf.SetFont(PDF_FONT, EMPTY_STRING, 10)
+	writer := &PdfWriter{Pdf: pdf, translate: pdf.UnicodeTranslatorFromDescriptor(EMPTY_STRING)}
+	pdf.SetTitle(title, true)
+	return writer
+}
+
+// Text returns the text in the encoding of the core fonts.
+func (writer *PdfWriter) Text(text string) string {
+	return writer.translate(text)
+}
+
+// consts for the tables of the generated PDF files
+const (
+	PDF_TABLE_FONT_SIZE   = 8
+	PDF_TABLE_LINE_HEIGHT = 4
+	PDF_CELL_PADDING      = 1
+	PDF_COLUMN_MIN_WIDTH  = 10
+	PDF_COLUMN_MAX_WIDTH  = 45
+	PDF_FOOTER_HEIGHT     = 12 // bottom space kept for the page footer
+)
+
+// PdfTable holds a table written across the pages of a PDF file. A table wider than the page is split into
+// column groups, each written on its own pages, repeating the leading columns so every row can be recognized.
+type PdfTable struct {
+	Header        []string
+	Rows          [][]string
+	RepeatColumns int     // leading columns repeated on every column group
+	ColumnUnits   [][]int // columns kept in the same column group, e.g. the details of an antigen
+}
+
+// SetPageFooter writes the given text and the page number at the bottom of every page.
+func (writer *PdfWriter) SetPageFooter(text string) {
+	pdf := writer.Pdf
+	pdf.SetAutoPageBreak(true, PDF_FOOTER_HEIGHT)
+	pdf.AliasNbPages(EMPTY_STRING)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-PDF_MARGIN)
+		pdf.SetFont(PDF_FONT, "I", PDF_TABLE_FONT_SIZE)
+		pdf.CellFormat(0, PDF_TABLE_LINE_HEIGHT, writer.Text(text), EMPTY_STRING, 0, "L", false, 0, EMPTY_STRING)
+		pdf.SetX(PDF_MARGIN)
+		pdf.CellFormat(0, PDF_TABLE_LINE_HEIGHT, fmt.Sprintf("Halaman %d dari {nb}", pdf.PageNo()), EMPTY_STRING, 0, "R", false, 0, EMPTY_STRING)
+	})
+}
+
+// WriteTitle writes the title of a page, centered.
+func (writer *PdfWriter) WriteTitle(title string) {
+	pdf := writer.Pdf
+	pdf.SetFont(PDF_FONT, "B", 14)
+	pdf.CellFormat(0, 8, writer.Text(title), EMPTY_STRING, 1, "C", false, 0, EMPTY_STRING)
+	pdf.Ln(2)
+}
+
+// WriteTable writes the table from the current position, starting a new page for every column group and
+// repeating the header on every page.
+func (writer *PdfWriter) WriteTable(table PdfTable) {
+	pdf := writer.Pdf
+	widths := writer.GetColumnWidths(table)
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	_, bottom := pdf.GetAutoPageBreak()
+
+	groups := GetColumnGroups(widths, table, pageWidth-left-right)
+	for i, columns := range groups {
+		if i > 0 {
+			pdf.AddPage()
+		}
+		if len(groups) > 1 {
+			pdf.SetFont(PDF_FONT, "I", PDF_TABLE_FONT_SIZE)
+			pdf.CellFormat(0, PDF_TABLE_LINE_HEIGHT, fmt.Sprintf("Bagian %d dari %d", i+1, len(groups)), EMPTY_STRING, 1, "L", false, 0, EMPTY_STRING)
+		}
+
+		writer.WriteTableRow(table.Header, columns, widths, true)
+		for _, row := range table.Rows {
+			if _, height := writer.SplitTableRow(row, columns, widths, false); pdf.GetY()+height > pageHeight-bottom {
+				pdf.AddPage()
+				writer.WriteTableRow(table.Header, columns, widths, true)
+			}
+			writer.WriteTableRow(row, columns, widths, false)
+		}
+	}
+}
+
+// GetColumnWidths returns the width of every column of the table, fitting its longest value and the longest
+// word of its header, within the minimum and maximum column width. Longer values are wrapped.
+func (writer *PdfWriter) GetColumnWidths(table PdfTable) []float64 {
+	pdf := writer.Pdf
+	widths := make([]float64, len(table.Header))
+	pdf.SetFont(PDF_FONT, "B", PDF_TABLE_FONT_SIZE)
+	for i, name := range table.Header {
+		for _, word := range strings.Fields(name) {
+			widths[i] = max(widths[i], pdf.GetStringWidth(writer.Text(word)))
+		}
+	}
+	pdf.SetFont(PDF_FONT, EMPTY_STRING, PDF_TABLE_FONT_SIZE)
+	for _, row := range table.Rows {
+		for i, value := range row {
+			widths[i] = max(widths[i], pdf.GetStringWidth(writer.Text(value)))
+		}
+	}
+	for i := range widths {
+		widths[i] = min(max(widths[i]+2*PDF_CELL_PADDING, PDF_COLUMN_MIN_WIDTH), PDF_COLUMN_MAX_WIDTH)
+	}
+	return widths
+}
+
+// GetColumnGroups splits the columns of the table into groups fitting the given width. Every group starts with
+// the repeated columns and the columns of a unit are never split across groups.
+func GetColumnGroups(widths []float64, table PdfTable, maxWidth float64) [][]int {
+	unitOf := make(map[int]int)
+	for i, unit := range table.ColumnUnits {
+		for _, column := range unit {
+			unitOf[column] = i
+		}
+	}
+
+	repeated := []int{}
+	repeatedWidth := 0.0
+	for column := 0; column < min(table.RepeatColumns, len(widths)); column++ {
+		repeated = append(repeated, column)
+		repeatedWidth += widths[column]
+	}
+
+	groups := [][]int{}
+	group, groupWidth := slices.Clone(repeated), repeatedWidth
+	addedUnits := make(map[int]bool)
+	for column := len(repeated); column < len(widths); column++ {
+		unit := []int{column}
+		if i, exists := unitOf[column]; exists {
+			if addedUnits[i] {
+				continue
+			}
+			addedUnits[i] = true
+			unit = table.ColumnUnits[i]
+		}
+
+		unitWidth := 0.0
+		for _, column := range unit {
+			unitWidth += widths[column]
+		}
+		if groupWidth+unitWidth > maxWidth && len(group) > len(repeated) {
+			groups = append(groups, group)
+			group, groupWidth = slices.Clone(repeated), repeatedWidth
+		}
+		group = append(group, unit...)
+		groupWidth += unitWidth
+	}
+	if len(group) > len(repeated) || len(groups) == 0 {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// SplitTableRow returns the lines of every given column of the row wrapped to the column width, and the
+// height of the row.
+func (writer *PdfWriter) SplitTableRow(values []string, columns []int, widths []float64, isHeader bool) ([][]string, float64) {
+	pdf := writer.Pdf
+	style := EMPTY_STRING
+	if isHeader {
+		style = "B"
+	}
+	pdf.SetFont(PDF_FONT, style, PDF_TABLE_FONT_SIZE)
+
+	lines := make([][]string, len(columns))
+	lineCount := 1
+	for i, column := range columns {
+		lines[i] = pdf.SplitText(writer.Text(values[column]), widths[column]-2*PDF_CELL_PADDING)
+		lineCount = max(lineCount, len(lines[i]))
+	}
+	return lines, float64(lineCount * PDF_TABLE_LINE_HEIGHT)
+}
+
+// WriteTableRow writes the given columns of a row of the table at the current position, the header row
+// filled in grey.
+func (writer *PdfWriter) WriteTableRow(values []string, columns []int, widths []float64, isHeader bool) {
+	pdf := writer.Pdf
+	lines, height := writer.SplitTableRow(values, columns, widths, isHeader)
+	style := "D"
+	if isHeader {
+		pdf.SetFillColor(220, 220, 220)
+		style = "FD"
+	}
+
+	x, y := pdf.GetX(), pdf.GetY()
+	for i, column := range columns {
+		pdf.Rect(x, y, widths[column], height, style)
+		for j, line := range lines[i] {
+			pdf.SetXY(x+PDF_CELL_PADDING, y+float64(j*PDF_TABLE_LINE_HEIGHT))
+			pdf.CellFormat(widths[column]-2*PDF_CELL_PADDING, PDF_TABLE_LINE_HEIGHT, line, EMPTY_STRING, 0, "L", false, 0, EMPTY_STRING)
+		}
+		x += widths[column]
+	}
+	pdf.SetXY(PDF_MARGIN, y+height)
+}
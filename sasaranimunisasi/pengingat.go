@@ -0,0 +1,281 @@
+package sasaranimunisasi
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// consts for the reminder (pengingat) messages
+const (
+	PENGINGAT_SHEET_NAME = "Pengingat"
+)
+
+// PengingatConfig holds the templates of the reminder messages sent to the parents of the children of the
+// generated list, e.g. through WhatsApp. Templates are Go text templates executed with a PengingatData.
+type PengingatConfig struct {
+	PhoneColumn        string         `yaml:"phone_column"`        // optional source column of the parent's phone number
+	HariPosyandu       int            `yaml:"hari_posyandu"`       // day of the month of the posyandu day, 0 when unknown
+	HariPerPosyandu    map[string]int `yaml:"hari_per_posyandu"`   // day of the month of the posyandu day per posyandu
+	TemplateTerlambat  string         `yaml:"template_terlambat"`  // message for a child with overdue antigens
+	TemplateBerikutnya string         `yaml:"template_berikutnya"` // message for a child without overdue antigens
+
+	hariPerPosyandu    map[string]int // posyandu days keyed by the normalized posyandu name
+	templateTerlambat  *template.Template
+	templateBerikutnya *template.Template
+}
+
+// PengingatData holds the fields available to the reminder templates.
+type PengingatData struct {
+	NamaOrangTua        string
+	NamaAnak            string
+	UsiaAnak            string
+	Posyandu            string
+	Puskesmas           string
+	ImunisasiTerlambat  []string
+	ImunisasiBerikutnya string
+	TanggalPosyandu     string // e.g. "Kamis, 10 Oktober 2024", empty when the posyandu day is unknown
+}
+
+// Pengingat represents the reminder message of a child, addressed to their parent.
+type Pengingat struct {
+	NomorHp      string `json:"nomorHp"` // empty when unknown
+	NamaOrangTua string `json:"namaOrangTua"`
+	NamaAnak     string `json:"namaAnak"`
+	Posyandu     string `json:"posyandu"`
+	Pesan        string `json:"pesan"`
+}
+
+// pengingatTemplateFuncs holds the functions available to the reminder templates
+var pengingatTemplateFuncs = template.FuncMap{"join": strings.Join}
+
+// Compile validates the posyandu days, keying them by the normalized posyandu name, and parses the templates.
+// It is safe to call more than once.
+func (cfg *PengingatConfig) Compile() error {
+	cfg.hariPerPosyandu = make(map[string]int, len(cfg.HariPerPosyandu))
+	for posyandu, hari := range cfg.HariPerPosyandu {
+		if hari < 1 || hari > 31 {
+			return fmt.Errorf("invalid hari_per_posyandu %d of %q, expected a day of the month", hari, posyandu)
+		}
+		name := NormalizeText(posyandu)
+		if _, exists := cfg.hariPerPosyandu[name]; exists {
+			return fmt.Errorf("duplicate hari_per_posyandu %q, posyandu names are matched case insensitively", posyandu)
+		}
+		cfg.hariPerPosyandu[name] = hari
+	}
+	if cfg.HariPosyandu < 0 || cfg.HariPosyandu > 31 {
+		return fmt.Errorf("invalid hari_posyandu %d, expected a day of the month or 0", cfg.HariPosyandu)
+	}
+
+	var err error
+	if cfg.templateTerlambat, err = parsePengingatTemplate("template_terlambat", cfg.TemplateTerlambat); err != nil {
+		return err
+	}
+	if cfg.templateBerikutnya, err = parsePengingatTemplate("template_berikutnya", cfg.TemplateBerikutnya); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parsePengingatTemplate parses a reminder template, returning nil when it is empty.
+func parsePengingatTemplate(name, text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == EMPTY_STRING {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Funcs(pengingatTemplateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pengingat %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// IsEnabled reports whether any reminder template is configured and valid.
+func (cfg *PengingatConfig) IsEnabled() bool {
+	return cfg.templateTerlambat != nil || cfg.templateBerikutnya != nil
+}
+
+// GetTanggalPosyandu returns the next posyandu day of the given posyandu from the reference date on, or a zero
+// time when its day of the month is not configured. A day past the end of a month falls on its last day.
+func (cfg *PengingatConfig) GetTanggalPosyandu(posyandu string, tanggalAcuan time.Time) time.Time {
+	hari := cfg.HariPosyandu
+	if hariPosyandu, exists := cfg.hariPerPosyandu[NormalizeText(posyandu)]; exists {
+		hari = hariPosyandu
+	}
+	if hari == 0 {
+		return time.Time{}
+	}
+
+	tanggalAcuan = ToDate(tanggalAcuan)
+	for months := 0; ; months++ {
+		firstDay := time.Date(tanggalAcuan.Year(), tanggalAcuan.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+		lastDay := firstDay.AddDate(0, 1, -1).Day()
+		if tanggal := firstDay.AddDate(0, 0, min(hari, lastDay)-1); !tanggal.Before(tanggalAcuan) {
+			return tanggal
+		}
+	}
+}
+
+// GetPesan executes the reminder template matching the child, the overdue one when any antigen is overdue and
+// it is configured. Returns an empty message when no template applies.
+func (cfg *PengingatConfig) GetPesan(data PengingatData) (string, error) {
+	tmpl := cfg.templateBerikutnya
+	if len(data.ImunisasiTerlambat) > 0 && cfg.templateTerlambat != nil {
+		tmpl = cfg.templateTerlambat
+	}
+	if tmpl == nil {
+		return EMPTY_STRING, nil
+	}
+
+	var pesan strings.Builder
+	if err := tmpl.Execute(&pesan, data); err != nil {
+		return EMPTY_STRING, err
+	}
+	return strings.TrimSpace(pesan.String()), nil
+}
+
+// GeneratePengingat processes the provided source file like GenerateFile and returns the reminder message of
+// every child of the generated lists.
+func (svc *SasaranImunisasiService) GeneratePengingat(sourceFile XlsxSourceFile) ([]Pengingat, error) {
+	fileGeneration, err := svc.ReadSourceFile(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	return fileGeneration.PengingatList, nil
+}
+
+// GetPengingat returns the reminder message of every child of the generated lists, in list order.
+// Children whose message cannot be generated are logged and skipped.
+func (fileGeneration *FileGeneration) GetPengingat() []Pengingat {
+	pengingatList := []Pengingat{}
+	for _, generation := range fileGeneration.Generations {
+		cfg := &generation.Cfg.Pengingat
+		if !cfg.IsEnabled() {
+			continue
+		}
+
+		for _, sasaranImunisasi := range generation.SasaranImunisasiList {
+			data := PengingatData{
+				NamaOrangTua:        sasaranImunisasi.NamaOrangTua,
+				NamaAnak:            sasaranImunisasi.NamaAnak,
+				UsiaAnak:            sasaranImunisasi.UsiaAnak,
+				Posyandu:            sasaranImunisasi.Posyandu,
+				Puskesmas:           sasaranImunisasi.Puskesmas,
+				ImunisasiTerlambat:  sasaranImunisasi.ImunisasiTerlambat,
+				ImunisasiBerikutnya: sasaranImunisasi.ImunisasiBerikutnya,
+			}
+			if tanggal := cfg.GetTanggalPosyandu(sasaranImunisasi.Posyandu, generation.TanggalAcuan); !tanggal.IsZero() {
+				data.TanggalPosyandu = GetTanggalLengkapStr(tanggal)
+			}
+
+			pesan, err := cfg.GetPesan(data)
+			if err != nil {
+				log.Printf("Error generating pengingat of %s (row %d): %v", sasaranImunisasi.NamaAnak, sasaranImunisasi.SourceRow, err)
+				continue
+			}
+			if pesan == EMPTY_STRING {
+				continue
+			}
+			pengingatList = append(pengingatList, Pengingat{
+				NomorHp:      sasaranImunisasi.NomorHp,
+				NamaOrangTua: sasaranImunisasi.NamaOrangTua,
+				NamaAnak:     sasaranImunisasi.NamaAnak,
+				Posyandu:     sasaranImunisasi.Posyandu,
+				Pesan:        pesan,
+			})
+		}
+	}
+	return pengingatList
+}
+
+// GetNomorHp returns the phone number of the parent read from the configured phone column, normalized for
+// WhatsApp with the 62 country code, e.g. "0812-3456 789" becomes "628123456789". Returns an empty string
+// when no phone column is configured or the cell holds no number.
+func (svc *SasaranImunisasiService) GetNomorHp(sourceColumnMap map[string]Column, getCellValue func(column Column) string) string {
+	column, exists := sourceColumnMap[svc.Cfg.Pengingat.PhoneColumn]
+	if svc.Cfg.Pengingat.PhoneColumn == EMPTY_STRING || !exists {
+		return EMPTY_STRING
+	}
+	return NormalizeNomorHp(getCellValue(column))
+}
+
+// NormalizeNomorHp keeps the digits of a phone number and replaces a leading 0 with the 62 country code.
+// When the cell holds several numbers, e.g. "0812 / 0813", only the first one is kept.
+func NormalizeNomorHp(value string) string {
+	value, _, _ = strings.Cut(value, "/")
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, value)
+
+	switch {
+	case len(digits) < 8:
+		return EMPTY_STRING
+	case strings.HasPrefix(digits, "0"):
+		return "62" + digits[1:]
+	case strings.HasPrefix(digits, "8"):
+		return "62" + digits
+	}
+	return digits
+}
+
+// WritePengingatCsv writes the reminder messages as a CSV of phone and message, the format bulk WhatsApp and
+// SMS senders import.
+func WritePengingatCsv(w io.Writer, pengingatList []Pengingat) error {
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write([]string{"phone", "message"})
+	for _, pengingat := range pengingatList {
+		csvWriter.Write([]string{pengingat.NomorHp, pengingat.Pesan})
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// GetPengingatTitle returns the title of the reminder sheet for the given sasaran type and reference date
+func GetPengingatTitle(sasaranType string, tanggalAcuan time.Time) string {
+	return fmt.Sprintf("%s %s %s", PENGINGAT_SHEET_NAME, CapitalizeFirstChar(sasaranType), GetDateStr(tanggalAcuan))
+}
+
+// PengingatSheet generates the sheet listing the reminder message of every child, to be copied into WhatsApp.
+// It implements NewXlsxGenerator.
+type PengingatSheet struct {
+	Title         string
+	PengingatList []Pengingat
+}
+
+// pengingatHeader holds the header of the reminder sheet
+var pengingatHeader = []string{"Nama Orang Tua", "Nama Anak", "Posyandu", "Nomor HP", "Pesan"}
+
+// SetTitle sets the title of the reminder sheet
+func (sheet *PengingatSheet) SetTitle(newFile NewXlsxFile) {
+	newFile.SetTitleRow(sheet.Title, len(pengingatHeader))
+}
+
+// SetHeader sets the header row of the reminder sheet
+func (sheet *PengingatSheet) SetHeader(newFile NewXlsxFile) {
+	newFile.SetRow(newFile.HeaderRowAt, ToInterfaces(pengingatHeader), newFile.HeaderStyle)
+}
+
+// SetBody sets the body rows of the reminder sheet, the message as plain text
+func (sheet *PengingatSheet) SetBody(newFile NewXlsxFile) {
+	for i, pengingat := range sheet.PengingatList {
+		nomorHp := pengingat.NomorHp
+		if nomorHp == EMPTY_STRING {
+			nomorHp = HYPHEN
+		}
+		values := []interface{}{pengingat.NamaOrangTua, pengingat.NamaAnak, pengingat.Posyandu, nomorHp, pengingat.Pesan}
+		newFile.SetRow(i+newFile.StartBodyRowAt, values, newFile.BodyStyle)
+	}
+}
+
+// SetColumnWidth sets the column width of the reminder sheet
+func (sheet *PengingatSheet) SetColumnWidth(newFile NewXlsxFile) {
+	newFile.StreamWriter.SetColWidth(1, len(pengingatHeader)-1, 24)
+	newFile.StreamWriter.SetColWidth(len(pengingatHeader), len(pengingatHeader), 80)
+}
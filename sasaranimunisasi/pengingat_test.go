@@ -0,0 +1,112 @@
+package sasaranimunisasi
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestNormalizeNomorHp(t *testing.T) {
+	tests := map[string]string{
+		"0812-3456 789":       "628123456789",
+		"+62 812 3456 789":    "628123456789",
+		"8123456789":          "628123456789",
+		"0812345678 / 081399": "62812345678",
+		"-":                   EMPTY_STRING,
+		"12345":               EMPTY_STRING,
+	}
+	for value, want := range tests {
+		if got := NormalizeNomorHp(value); got != want {
+			t.Errorf("NormalizeNomorHp(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestGetTanggalPosyandu(t *testing.T) {
+	cfg := PengingatConfig{HariPosyandu: 10, HariPerPosyandu: map[string]int{" Posyandu  Melati": 31}}
+	if err := cfg.Compile(); err != nil {
+		t.Fatalf("compiling config: %v", err)
+	}
+	tests := []struct {
+		posyandu     string
+		tanggalAcuan time.Time
+		want         time.Time
+	}{
+		{"Posyandu Wanasari", time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC), time.Date(2024, time.October, 10, 0, 0, 0, 0, time.UTC)},
+		{"Posyandu Wanasari", time.Date(2024, time.October, 10, 9, 0, 0, 0, time.UTC), time.Date(2024, time.October, 10, 0, 0, 0, 0, time.UTC)},
+		{"Posyandu Wanasari", time.Date(2024, time.December, 11, 0, 0, 0, 0, time.UTC), time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC)},
+		{"posyandu melati", time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC), time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"POSYANDU MELATI ", time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC), time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		if got := cfg.GetTanggalPosyandu(test.posyandu, test.tanggalAcuan); !got.Equal(test.want) {
+			t.Errorf("GetTanggalPosyandu(%q, %s) = %s, want %s", test.posyandu, test.tanggalAcuan, got, test.want)
+		}
+	}
+}
+
+func TestPengingatCompile(t *testing.T) {
+	for name, cfg := range map[string]PengingatConfig{
+		"duplicate posyandu": {HariPerPosyandu: map[string]int{"Posyandu Melati": 10, "posyandu  melati": 15}},
+		"invalid day":        {HariPerPosyandu: map[string]int{"Posyandu Melati": 32}},
+		"invalid default":    {HariPosyandu: -1},
+	} {
+		if err := cfg.Compile(); err == nil {
+			t.Errorf("%s: got no error for an invalid config", name)
+		}
+	}
+}
+
+func TestGeneratePengingat(t *testing.T) {
+	cfg := loadTestConfig(t)
+	cfg.Pengingat = PengingatConfig{
+		PhoneColumn:        "No HP",
+		HariPosyandu:       10,
+		TemplateTerlambat:  "{{.NamaOrangTua}}: {{join .ImunisasiTerlambat \", \"}} terlambat, datang {{.TanggalPosyandu}}",
+		TemplateBerikutnya: "{{.NamaOrangTua}}: berikutnya {{.ImunisasiBerikutnya}}",
+	}
+	svc := newTestService(t, cfg)
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC) }
+
+	file := excelize.NewFile()
+	defer file.Close()
+	rows := [][]interface{}{
+		{NAMA_ANAK, TANGGAL_LAHIR_ANAK, NAMA_ORANG_TUA, "no  HP", "Tanggal Imunisasi HB0", "Status Imunisasi HB0"},
+		{"Ahmad", "2024-09-20", "Ibu Ahmad", "0812-3456-789", "2024-09-20", "ideal"},
+		{"Siti", "2024-09-01", "Ibu Siti", nil, nil, "belum"},
+	}
+	for i, values := range rows {
+		file.SetSheetRow(SHEET_NAME, fmt.Sprintf("A%d", i+1), &values)
+	}
+
+	pengingatList, err := svc.GeneratePengingat(XlsxSourceFile{
+		Ctx:       context.WithValue(context.Background(), sasaranTypeKey, BAYI),
+		SheetName: SHEET_NAME,
+		Reader:    NewXlsxReader(file),
+	})
+	if err != nil {
+		t.Fatalf("generating pengingat: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePengingatCsv(&buf, pengingatList); err != nil {
+		t.Fatalf("writing pengingat CSV: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading pengingat CSV: %v", err)
+	}
+	want := [][]string{
+		{"phone", "message"},
+		{EMPTY_STRING, "Ibu Siti: HB0, BCG 1, POLIO 1 terlambat, datang Kamis, 10 Oktober 2024"},
+		{"628123456789", "Ibu Ahmad: berikutnya BCG 1, POLIO 1"},
+	}
+	if fmt.Sprint(records) != fmt.Sprint(want) {
+		t.Errorf("got pengingat CSV %q, want %q", records, want)
+	}
+}
@@ -0,0 +1,157 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// consts for splitting the target list per posyandu
+const (
+	PISAH_POSYANDU_SHEET = "sheet" // one sheet per posyandu in the generated file
+	PISAH_POSYANDU_ZIP   = "zip"   // a ZIP containing the generated file and one workbook per posyandu
+	TANPA_POSYANDU       = "Tanpa Posyandu"
+	MAX_SHEET_NAME_LEN   = 31
+)
+
+// ValidatePisahPosyandu validates the requested per-posyandu split mode, an empty value means no split.
+func ValidatePisahPosyandu(pisahPosyandu string) (string, error) {
+	pisahPosyandu = strings.ToLower(strings.TrimSpace(pisahPosyandu))
+	switch pisahPosyandu {
+	case EMPTY_STRING, PISAH_POSYANDU_SHEET, PISAH_POSYANDU_ZIP:
+		return pisahPosyandu, nil
+	}
+	return EMPTY_STRING, fmt.Errorf("invalid pisahPosyandu %q, accepted values: %s, %s", pisahPosyandu, PISAH_POSYANDU_SHEET, PISAH_POSYANDU_ZIP)
+}
+
+// GetPosyandu returns the posyandu of a child, read from the configured posyandu column of the source file
+// or, when none is configured, the Pos Imunisasi of the most recent immunization of the given antigens.
+func (svc *SasaranImunisasiService) GetPosyandu(sasaranImunisasi SasaranImunisasi, imunisasi []string, sourceColumnMap map[string]Column, getCellValue func(column Column) string) string {
+	posyandu := HYPHEN
+	if svc.Cfg.PosyanduColumn != EMPTY_STRING {
+		if column, exists := sourceColumnMap[svc.Cfg.PosyanduColumn]; exists {
+			posyandu = getCellValue(column)
+		}
+	} else {
+		posyandu = sasaranImunisasi.GetPosImunisasiTerakhir(imunisasi)
+	}
+
+	if posyandu = strings.TrimSpace(posyandu); posyandu == HYPHEN || posyandu == EMPTY_STRING {
+		return TANPA_POSYANDU
+	}
+	return posyandu
+}
+
+// GetPosImunisasiTerakhir returns the Pos Imunisasi of the most recent immunization of the given antigens, or "-"
+// when none was recorded. When several antigens share the most recent date, the last one in the given order wins,
+// so the posyandu does not depend on map order.
+func (sasaranImunisasi *SasaranImunisasi) GetPosImunisasiTerakhir(imunisasi []string) string {
+	var latestDate time.Time
+	latestPos := HYPHEN
+	for _, imun := range imunisasi {
+		detailImunisasi := sasaranImunisasi.DetailImunisasi[imun]
+		for _, date := range detailImunisasi.Tanggal {
+			if date.Before(latestDate) {
+				continue
+			}
+			for _, pos := range detailImunisasi.Pos {
+				if pos != HYPHEN {
+					latestDate, latestPos = date, pos
+				}
+			}
+		}
+	}
+	return latestPos
+}
+
+// SplitByPosyandu returns one generation per posyandu, sorted by posyandu name, each holding the children of that
+// posyandu in the original order.
+func (gen *SasaranImunisasiGeneration) SplitByPosyandu() []*SasaranImunisasiGeneration {
+	groups := make(map[string][]SasaranImunisasi)
+	for _, sasaranImunisasi := range gen.SasaranImunisasiList {
+		groups[sasaranImunisasi.Posyandu] = append(groups[sasaranImunisasi.Posyandu], sasaranImunisasi)
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	generations := []*SasaranImunisasiGeneration{}
+	for _, name := range names {
+		posyanduGeneration := *gen
+		posyanduGeneration.Posyandu = name
+		posyanduGeneration.SasaranImunisasiList = groups[name]
+		generations = append(generations, &posyanduGeneration)
+	}
+	return generations
+}
+
+// GetPosyanduFiles returns one generated file per posyandu, each containing the sasaran sheets of that posyandu.
+func (fileGeneration *FileGeneration) GetPosyanduFiles() ([]*XlsxGeneratedFile, error) {
+	posyanduSheets := make(map[string][]XlsxSheet)
+	for _, generation := range fileGeneration.Generations {
+		for _, posyanduGeneration := range generation.SplitByPosyandu() {
+			sheetName := SHEET_NAME
+			if len(fileGeneration.Generations) > 1 {
+				sheetName = generation.GetSheetName()
+			}
+			posyanduSheets[posyanduGeneration.Posyandu] = append(posyanduSheets[posyanduGeneration.Posyandu],
+				XlsxSheet{Name: sheetName, Generator: posyanduGeneration})
+		}
+	}
+
+	names := make([]string, 0, len(posyanduSheets))
+	for name := range posyanduSheets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	posyanduFiles := []*XlsxGeneratedFile{}
+	for _, name := range names {
+		excelFile, err := CreateNewXlsxWorkbook(fileGeneration.Ctx, posyanduSheets[name])
+		if err != nil {
+			return nil, err
+		}
+		posyanduFiles = append(posyanduFiles, &XlsxGeneratedFile{
+			FileName:     GetFileName(fileGeneration.SasaranType, fileGeneration.TanggalAcuan) + SPACE + GetValidFileName(name) + ".xlsx",
+			ExcelizeFile: excelFile,
+		})
+	}
+	return posyanduFiles, nil
+}
+
+// GetValidSheetName returns a sheet name accepted by Excel, without forbidden characters, at most 31 characters
+// long and not yet in usedNames. The returned name is added to usedNames.
+func GetValidSheetName(name string, usedNames map[string]bool) string {
+	name = strings.NewReplacer(":", SPACE, "\\", SPACE, "/", SPACE, "?", SPACE, "*", SPACE, "[", "(", "]", ")").Replace(name)
+	name = strings.Join(strings.Fields(name), SPACE)
+	if name == EMPTY_STRING {
+		name = TANPA_POSYANDU
+	}
+
+	validName := truncateRunes(name, MAX_SHEET_NAME_LEN)
+	for i := 2; usedNames[strings.ToLower(validName)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		validName = truncateRunes(name, MAX_SHEET_NAME_LEN-len(suffix)) + suffix
+	}
+	usedNames[strings.ToLower(validName)] = true
+	return validName
+}
+
+// GetValidFileName returns the name without characters that are not allowed in file names.
+func GetValidFileName(name string) string {
+	return strings.NewReplacer("/", "-", "\\", "-", ":", "-", "*", EMPTY_STRING, "?", EMPTY_STRING, `"`, EMPTY_STRING,
+		"<", EMPTY_STRING, ">", EMPTY_STRING, "|", "-").Replace(name)
+}
+
+// truncateRunes returns at most max runes of value.
+func truncateRunes(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+	return strings.TrimSpace(string(runes[:max]))
+}
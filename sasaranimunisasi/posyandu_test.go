@@ -0,0 +1,51 @@
+package sasaranimunisasi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetPosImunisasiTerakhir(t *testing.T) {
+	detail := func(imun, pos string, tanggal time.Time) DetailImunisasi {
+		return DetailImunisasi{
+			Tanggal: map[string]time.Time{"Tanggal Imunisasi " + imun: tanggal},
+			Pos:     map[string]string{"Pos Imunisasi " + imun: pos},
+		}
+	}
+	imunisasi := []string{"HB0", "BCG 1", "POLIO 1", "DPT-Hb-Hib 1"}
+
+	tests := []struct {
+		name            string
+		detailImunisasi map[string]DetailImunisasi
+		want            string
+	}{
+		{"latest date", map[string]DetailImunisasi{
+			"HB0":   detail("HB0", "Posyandu Melati", date(2024, time.January, 10)),
+			"BCG 1": detail("BCG 1", "Posyandu Mawar", date(2024, time.February, 10)),
+		}, "Posyandu Mawar"},
+		{"same date, last antigen in order", map[string]DetailImunisasi{
+			"BCG 1":        detail("BCG 1", "Posyandu Melati", date(2024, time.February, 10)),
+			"POLIO 1":      detail("POLIO 1", "Posyandu Mawar", date(2024, time.February, 10)),
+			"DPT-Hb-Hib 1": detail("DPT-Hb-Hib 1", "Posyandu Kenanga", date(2024, time.February, 10)),
+			"HB0":          detail("HB0", "Posyandu Anggrek", date(2024, time.January, 10)),
+		}, "Posyandu Kenanga"},
+		{"latest date without pos", map[string]DetailImunisasi{
+			"HB0":   detail("HB0", "Posyandu Melati", date(2024, time.January, 10)),
+			"BCG 1": detail("BCG 1", HYPHEN, date(2024, time.February, 10)),
+		}, "Posyandu Melati"},
+		{"antigen outside the given ones", map[string]DetailImunisasi{
+			"HB0":  detail("HB0", "Posyandu Melati", date(2024, time.January, 10)),
+			"MR 1": detail("MR 1", "Posyandu Mawar", date(2024, time.October, 10)),
+		}, "Posyandu Melati"},
+		{"no immunization", map[string]DetailImunisasi{}, HYPHEN},
+	}
+	for _, test := range tests {
+		sasaranImunisasi := SasaranImunisasi{DetailImunisasi: test.detailImunisasi}
+		// run several times, as the detail imunisasi map is iterated in random order
+		for range 10 {
+			if got := sasaranImunisasi.GetPosImunisasiTerakhir(imunisasi); got != test.want {
+				t.Fatalf("%s: got %q, want %q", test.name, got, test.want)
+			}
+		}
+	}
+}
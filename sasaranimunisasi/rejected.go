@@ -0,0 +1,117 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"time"
+)
+
+// RejectedRow represents a source row excluded from the generated file together with the reason.
+type RejectedRow struct {
+	RowNumber   int    `json:"rowNumber"`
+	NamaAnak    string `json:"namaAnak"`
+	SasaranType string `json:"sasaranType,omitempty"` // empty when the row is rejected for every sasaran type
+	Reason      string `json:"reason"`
+	Detail      string `json:"detail,omitempty"`
+}
+
+// consts for rejected row reason codes
+const (
+	REASON_OUT_OF_AREA        = "out_of_area"
+	REASON_FULLY_IMMUNIZED    = "fully_immunized"
+	REASON_INVALID_BIRTH_DATE = "invalid_birth_date"
+	REASON_MISSING_NAME       = "missing_name"
+	REASON_DUPLICATE          = "duplicate"
+	REASON_OUT_OF_AGE_RANGE   = "out_of_age_range"
+	REJECTED_SHEET_NAME       = "Baris Ditolak"
+)
+
+// reasonDescriptions holds the description shown to the user for each reason code
+var reasonDescriptions = map[string]string{
+	REASON_OUT_OF_AREA:        "Di luar wilayah",
+	REASON_FULLY_IMMUNIZED:    "Imunisasi sudah lengkap",
+	REASON_INVALID_BIRTH_DATE: "Tanggal lahir tidak valid",
+	REASON_MISSING_NAME:       "Nama anak kosong",
+	REASON_DUPLICATE:          "Duplikat",
+	REASON_OUT_OF_AGE_RANGE:   "Usia di luar sasaran",
+}
+
+// Reject records the given source row as rejected and counts it in the summary.
+func (fileGeneration *FileGeneration) Reject(rejectedRow RejectedRow) {
+	fileGeneration.RejectedRows = append(fileGeneration.RejectedRows, rejectedRow)
+
+	summary := &fileGeneration.Summary
+	if summary.RejectedByReason == nil {
+		summary.RejectedByReason = make(map[string]int)
+	}
+	summary.RejectedRows++
+	summary.RejectedByReason[rejectedRow.Reason]++
+}
+
+// GetRejectReason checks a sasaran imunisasi of the generation, once its duplicates are merged and its jadwal
+// imunisasi computed, and returns the reason code and detail when the row must be excluded from the generation.
+// A child without any due or overdue antigen is left out.
+func (gen *SasaranImunisasiGeneration) GetRejectReason(sasaranImunisasi SasaranImunisasi) (string, string) {
+	if sasaranImunisasi.CountNonIdealImmunizations(gen.Imunisasi) == 0 {
+		return REASON_FULLY_IMMUNIZED, EMPTY_STRING
+	}
+
+	return EMPTY_STRING, EMPTY_STRING
+}
+
+// GetOutOfAgeRangeDetail describes why a child of the given age in months is not part of the generated file.
+func GetOutOfAgeRangeDetail(sasaranType string, usiaBulan int) string {
+	if sasaranType == EMPTY_STRING {
+		return fmt.Sprintf("usia %d bulan, bukan bayi atau baduta", usiaBulan)
+	}
+	return fmt.Sprintf("usia %d bulan, termasuk sasaran %s", usiaBulan, sasaranType)
+}
+
+// RejectedRowsSheet generates the sheet listing every source row excluded from the generated file.
+// It implements NewXlsxGenerator.
+type RejectedRowsSheet struct {
+	Title        string
+	RejectedRows []RejectedRow
+}
+
+// rejectedRowsHeader holds the header of the rejected rows sheet
+var rejectedRowsHeader = []string{"Baris", "Nama Anak", "Sasaran", "Kode Alasan", "Alasan", "Keterangan"}
+
+// SetTitle sets the title of the rejected rows sheet
+func (sheet *RejectedRowsSheet) SetTitle(newFile NewXlsxFile) {
+	newFile.SetTitleRow(sheet.Title, len(rejectedRowsHeader))
+}
+
+// SetHeader sets the header row of the rejected rows sheet
+func (sheet *RejectedRowsSheet) SetHeader(newFile NewXlsxFile) {
+	newFile.SetRow(newFile.HeaderRowAt, ToInterfaces(rejectedRowsHeader), newFile.HeaderStyle)
+}
+
+// SetBody sets the body rows of the rejected rows sheet
+func (sheet *RejectedRowsSheet) SetBody(newFile NewXlsxFile) {
+	for i, rejectedRow := range sheet.RejectedRows {
+		sasaranType := HYPHEN
+		if rejectedRow.SasaranType != EMPTY_STRING {
+			sasaranType = CapitalizeFirstChar(rejectedRow.SasaranType)
+		}
+		values := []interface{}{
+			rejectedRow.RowNumber,
+			rejectedRow.NamaAnak,
+			sasaranType,
+			rejectedRow.Reason,
+			reasonDescriptions[rejectedRow.Reason],
+			rejectedRow.Detail,
+		}
+		newFile.SetRow(i+newFile.StartBodyRowAt, values, newFile.BodyStyle)
+	}
+}
+
+// SetColumnWidth sets the column width of the rejected rows sheet
+func (sheet *RejectedRowsSheet) SetColumnWidth(newFile NewXlsxFile) {
+	newFile.StreamWriter.SetColWidth(1, 1, 10)
+	newFile.StreamWriter.SetColWidth(2, len(rejectedRowsHeader), 32)
+}
+
+// GetRejectedTitle returns the title of the rejected rows sheet for the given sasaran type and reference date
+func GetRejectedTitle(sasaranType string, tanggalAcuan time.Time) string {
+	return fmt.Sprintf("%s %s %s", REJECTED_SHEET_NAME, CapitalizeFirstChar(sasaranType), GetDateStr(tanggalAcuan))
+}
@@ -0,0 +1,41 @@
+package sasaranimunisasi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetRejectReason(t *testing.T) {
+	svc := newTestService(t, loadTestConfig(t))
+	gen := svc.NewGeneration(BAYI, date(2024, time.October, 3))
+	status := func(statuses map[string]int) map[string]DetailImunisasi {
+		detailImunisasi := make(map[string]DetailImunisasi)
+		for imun, value := range statuses {
+			detailImunisasi[imun] = DetailImunisasi{Status: map[string]int{STATUS + SPACE + imun: value}}
+		}
+		return detailImunisasi
+	}
+
+	tests := []struct {
+		name            string
+		birthDate       time.Time
+		detailImunisasi map[string]DetailImunisasi
+		want            string
+	}{
+		// the source statuses are all ideal, but DPT-Hb-Hib 1, POLIO 2, PCV 1 and ROTA 1 are overdue
+		{"ideal source statuses with overdue antigens", date(2024, time.June, 1),
+			status(map[string]int{"HB0": 0, "BCG 1": 0, "POLIO 1": 0}), EMPTY_STRING},
+		// the non-ideal source status of MR 1 is not due yet
+		{"non-ideal source status not due yet", date(2024, time.September, 1),
+			status(map[string]int{"HB0": 0, "BCG 1": 0, "POLIO 1": 0, "MR 1": 1}), REASON_FULLY_IMMUNIZED},
+		{"due antigen without a source status", date(2024, time.September, 1),
+			status(map[string]int{"HB0": 0, "BCG 1": 0}), EMPTY_STRING},
+	}
+	for _, test := range tests {
+		sasaranImunisasi := SasaranImunisasi{NamaAnak: test.name, TanggalLahirAnak: test.birthDate, DetailImunisasi: test.detailImunisasi}
+		sasaranImunisasi.SetJadwalImunisasi(gen.JadwalImunisasi, gen.TanggalAcuan)
+		if got, _ := gen.GetRejectReason(sasaranImunisasi); got != test.want {
+			t.Errorf("%s: got reject reason %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// consts for the layouts of the sasaran sheets and of the print-ready PDF file
+const (
+	LAYOUT_LENGKAP             = "lengkap" // every column, with the Tanggal, Pos and Status columns of every antigen
+	LAYOUT_RINGKAS             = "ringkas" // the base columns and the non-ideal antigens, one row per child
+	IMUNISASI_BELUM            = "Imunisasi Belum Lengkap"
+	JUMLAH_IMUNISASI_BELUM     = "Jumlah Imunisasi Belum"
+	TANGGAL_IMUNISASI_TERAKHIR = "Tanggal Imunisasi Terakhir"
+)
+
+// ValidateLayout validates the requested layout of the sasaran sheets, an empty value means the configured layout.
+func ValidateLayout(layout string) (string, error) {
+	layout = strings.ToLower(strings.TrimSpace(layout))
+	switch layout {
+	case EMPTY_STRING, LAYOUT_LENGKAP, LAYOUT_RINGKAS:
+		return layout, nil
+	}
+	return EMPTY_STRING, fmt.Errorf("invalid layout %q, accepted values: %s, %s", layout, LAYOUT_LENGKAP, LAYOUT_RINGKAS)
+}
+
+// IsRingkas reports whether the generated sheet lists the non-ideal antigens instead of the detail columns of
+// every antigen.
+func (gen *SasaranImunisasiGeneration) IsRingkas() bool {
+	return gen.Layout == LAYOUT_RINGKAS
+}
+
+// getBaseColumnCount returns the number of base and schedule columns, which come before the detail columns
+// of the antigens.
+func (gen *SasaranImunisasiGeneration) getBaseColumnCount() int {
+	return GetColumnIndex(gen.SasaranColumnMap[TERLAMBAT].Label) + 1
+}
+
+// GetRingkasHeader returns the header of the ringkas layout: the base and schedule columns followed by the date
+// of the last immunization, the number of non-ideal antigens and their list.
+func (gen *SasaranImunisasiGeneration) GetRingkasHeader() []string {
+	header := slices.Clone(gen.GetLengkapHeader()[:gen.getBaseColumnCount()])
+	return append(header, TANGGAL_IMUNISASI_TERAKHIR, JUMLAH_IMUNISASI_BELUM, IMUNISASI_BELUM)
+}
+
+// GetRingkasRow returns the values of the row of a child in the ringkas layout, ordered like GetRingkasHeader.
+// The non-ideal antigens are listed in schedule order.
+func (gen *SasaranImunisasiGeneration) GetRingkasRow(sasaranImunisasi SasaranImunisasi) []interface{} {
+	baseColumns := gen.getBaseColumnCount()
+	values := gen.GetLengkapRow(sasaranImunisasi)[:baseColumns:baseColumns]
+
+	var tanggalTerakhir interface{} = HYPHEN
+	if tanggal := sasaranImunisasi.GetTanggalImunisasiTerakhir(); !tanggal.IsZero() {
+		tanggalTerakhir = tanggal
+	}
+	imunisasiBelum := sasaranImunisasi.GetImunisasiBelum(gen.GetImunisasiByJadwal())
+	imunisasiBelumStr := HYPHEN
+	if len(imunisasiBelum) > 0 {
+		imunisasiBelumStr = strings.Join(imunisasiBelum, ", ")
+	}
+	return append(values, tanggalTerakhir, len(imunisasiBelum), imunisasiBelumStr)
+}
+
+// GetImunisasiByJadwal returns the antigens of the generated sheet in schedule order, as configured in
+// jadwal_imunisasi, followed by the antigens without a schedule.
+func (gen *SasaranImunisasiGeneration) GetImunisasiByJadwal() []string {
+	imunisasi := []string{}
+	for _, jadwal := range gen.JadwalImunisasi {
+		imunisasi = append(imunisasi, jadwal.Imunisasi)
+	}
+	for _, imun := range gen.Imunisasi {
+		if !slices.Contains(imunisasi, imun) {
+			imunisasi = append(imunisasi, imun)
+		}
+	}
+	return imunisasi
+}
+
+// GetTanggalImunisasiTerakhir returns the date of the last immunization received by the child, zero when the
+// source file holds no immunization date.
+func (sasaranImunisasi *SasaranImunisasi) GetTanggalImunisasiTerakhir() time.Time {
+	var tanggalTerakhir time.Time
+	for _, detailImunisasi := range sasaranImunisasi.DetailImunisasi {
+		for _, tanggal := range detailImunisasi.Tanggal {
+			if tanggal.After(tanggalTerakhir) {
+				tanggalTerakhir = tanggal
+			}
+		}
+	}
+	return tanggalTerakhir
+}
@@ -0,0 +1,113 @@
+package sasaranimunisasi
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestValidateLayout(t *testing.T) {
+	tests := map[string]string{
+		EMPTY_STRING: EMPTY_STRING,
+		"lengkap":    LAYOUT_LENGKAP,
+		" Ringkas ":  LAYOUT_RINGKAS,
+	}
+	for layout, want := range tests {
+		if got, err := ValidateLayout(layout); err != nil || got != want {
+			t.Errorf("ValidateLayout(%q) = %q, %v, want %q", layout, got, err, want)
+		}
+	}
+	if _, err := ValidateLayout("pendek"); err == nil {
+		t.Errorf("got no error for an unknown layout")
+	}
+}
+
+func TestGetTanggalImunisasiTerakhir(t *testing.T) {
+	sasaranImunisasi := SasaranImunisasi{DetailImunisasi: map[string]DetailImunisasi{
+		"HB0":   {Tanggal: map[string]time.Time{"Tanggal Imunisasi HB0": time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)}},
+		"BCG 1": {Tanggal: map[string]time.Time{"Tanggal Imunisasi BCG 1": time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)}},
+		IDL_1:   {Status: map[string]int{STATUS_IDL_1: 1}},
+	}}
+	if got, want := sasaranImunisasi.GetTanggalImunisasiTerakhir(), time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got tanggal imunisasi terakhir %v, want %v", got, want)
+	}
+	if got := (&SasaranImunisasi{}).GetTanggalImunisasiTerakhir(); !got.IsZero() {
+		t.Errorf("got tanggal imunisasi terakhir %v without any date, want zero", got)
+	}
+}
+
+func TestGetRingkasRowImunisasiBelum(t *testing.T) {
+	svc := newTestService(t, loadTestConfig(t))
+	gen := svc.NewGeneration(BAYI, date(2024, time.October, 3))
+	given := func(imunisasi ...string) map[string]DetailImunisasi {
+		detailImunisasi := make(map[string]DetailImunisasi)
+		for _, imun := range imunisasi {
+			detailImunisasi[imun] = DetailImunisasi{Status: map[string]int{STATUS + SPACE + imun: 0}}
+		}
+		return detailImunisasi
+	}
+
+	tests := []struct {
+		name            string
+		birthDate       time.Time
+		detailImunisasi map[string]DetailImunisasi
+		wantJumlah      int
+		want            string
+	}{
+		{"newborn", date(2024, time.October, 1), given(), 3, "HB0, BCG 1, POLIO 1"},
+		{"young infant", date(2024, time.August, 1), given("HB0", "BCG 1", "POLIO 1"), 4, "DPT-Hb-Hib 1, POLIO 2, PCV 1, ROTA 1"},
+		{"nothing due", date(2024, time.September, 20), given("HB0", "BCG 1", "POLIO 1"), 0, HYPHEN},
+		// a recorded date counts as given, like in the status columns, whatever the source status says
+		{"recorded date with a non-ideal status", date(2024, time.September, 20), map[string]DetailImunisasi{
+			"HB0": {Tanggal: map[string]time.Time{"Tanggal Imunisasi HB0": date(2024, time.September, 20)}, Status: map[string]int{"Status Imunisasi HB0": 1}},
+		}, 2, "BCG 1, POLIO 1"},
+	}
+	for _, test := range tests {
+		sasaranImunisasi := SasaranImunisasi{NamaAnak: test.name, TanggalLahirAnak: test.birthDate, DetailImunisasi: test.detailImunisasi}
+		sasaranImunisasi.SetJadwalImunisasi(gen.JadwalImunisasi, gen.TanggalAcuan)
+		row := gen.GetRingkasRow(sasaranImunisasi)
+		if jumlah, got := row[len(row)-2], row[len(row)-1]; jumlah != test.wantJumlah || got != test.want {
+			t.Errorf("%s: got %v imunisasi belum %q, want %d %q", test.name, jumlah, got, test.wantJumlah, test.want)
+		}
+		// the same antigens keep the child in the generated sheet
+		if got := sasaranImunisasi.CountNonIdealImmunizations(gen.Imunisasi); got != test.wantJumlah {
+			t.Errorf("%s: got %d non-ideal imunisasi, want %d", test.name, got, test.wantJumlah)
+		}
+	}
+}
+
+func TestGenerateFileRingkas(t *testing.T) {
+	cfg := loadTestConfig(t)
+	svc := newTestService(t, cfg)
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC) }
+
+	sourceFile := newGoldenSourceXlsx(t, cfg, cfg.ImunisasiBayi)
+	defer sourceFile.Reader.Close()
+	ctx := context.WithValue(context.Background(), sasaranTypeKey, BAYI)
+	sourceFile.Ctx = context.WithValue(ctx, layoutKey, LAYOUT_RINGKAS)
+	fileGeneration, err := svc.ReadSourceFile(sourceFile)
+	if err != nil {
+		t.Fatalf("reading source file: %v", err)
+	}
+
+	// the status colors have no column to explain or format in the ringkas layout
+	generatedFile, err := fileGeneration.NewXlsxGeneratedFile()
+	if err != nil {
+		t.Fatalf("generating file: %v", err)
+	}
+	if xml := sheetXml(t, generatedFile.ExcelizeFile); bytes.Contains(xml, []byte("<conditionalFormatting")) {
+		t.Errorf("got conditional formats on the ringkas sheet")
+	}
+	if rows, _ := generatedFile.ExcelizeFile.GetRows(SHEET_NAME); len(rows[1]) != 0 {
+		t.Errorf("got status legend %v on the ringkas sheet, want an empty row", rows[1])
+	}
+
+	// the layout of the request takes precedence over the configured PDF layout, the ringkas table needing no
+	// column groups
+	ringkasPages := fileGeneration.NewSasaranPdf().Pdf.PageCount()
+	fileGeneration.Ctx = context.WithValue(ctx, layoutKey, LAYOUT_LENGKAP)
+	if lengkapPages := fileGeneration.NewSasaranPdf().Pdf.PageCount(); ringkasPages >= lengkapPages {
+		t.Errorf("got %d ringkas pages and %d lengkap pages, want fewer ringkas pages", ringkasPages, lengkapPages)
+	}
+}
@@ -0,0 +1,153 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// requiredSourceHeaders holds the source headers without which no child can be read, required when
+// required_columns is not configured
+var requiredSourceHeaders = []string{NAMA_ANAK, TANGGAL_LAHIR_ANAK}
+
+// SourceSheetScore holds how well the header of a source sheet matches the configured columns.
+type SourceSheetScore struct {
+	SheetName      string
+	Score          int      // number of configured column_name and detail_imunisasi headers found
+	MissingHeaders []string // required headers not found
+}
+
+// SheetNotFoundError is returned when neither the requested sheet nor any other sheet of the source file
+// contains the required headers. It lists the sheets of the file so the user can pick the right one.
+type SheetNotFoundError struct {
+	SheetName       string // requested sheet name, empty when the sheet was to be detected
+	AvailableSheets []string
+	MissingHeaders  []string // required headers missing in the best matching sheet
+}
+
+// Error describes the requested sheet, the available sheets and the missing required headers.
+func (err *SheetNotFoundError) Error() string {
+	message := "no sheet contains the sasaran imunisasi headers"
+	if err.SheetName != EMPTY_STRING {
+		message = fmt.Sprintf("sheet %q not found or does not contain the sasaran imunisasi headers", err.SheetName)
+	}
+	return fmt.Sprintf("%s; available sheets: %s; missing required headers: %s", message,
+		strings.Join(err.AvailableSheets, ", "), strings.Join(err.MissingHeaders, ", "))
+}
+
+// GetExpectedSourceHeaders returns the source headers configured in column_name, detail_imunisasi and
+// detail_imunisasi_lengkap for every bayi and baduta antigen. Computed columns are not expected.
+func (cfg *SasaranImunisasiConfig) GetExpectedSourceHeaders() []string {
+	headers := []string{}
+	for _, columnName := range cfg.ColumnName {
+		if !IsComputedColumn(columnName) {
+			headers = append(headers, columnName)
+		}
+	}
+
+	for _, imunisasi := range [][]string{cfg.ImunisasiBayi, cfg.ImunisasiBaduta} {
+		for _, imun := range imunisasi {
+			headers = append(headers, cfg.GetDetailColumnNames(imun)...)
+		}
+	}
+	return headers
+}
+
+// SelectSourceSheet sets the sheet of the source file to read. The requested sheet is kept when it contains
+// the required headers, matching its name case-insensitively; otherwise, including when no sheet is requested,
+// the sheet with the required headers whose header matches the most configured columns is picked. Returns a
+// SheetNotFoundError when no sheet contains the required headers.
+func (svc *SasaranImunisasiService) SelectSourceSheet(sourceFile *XlsxSourceFile) error {
+	scores, err := svc.ScoreSourceSheets(sourceFile.Reader)
+	if err != nil {
+		return err
+	}
+
+	// best is the best matching sheet with every required header, closest the best matching sheet of all
+	requestedSheet := strings.TrimSpace(sourceFile.SheetName)
+	var best, closest, requested *SourceSheetScore
+	for i := range scores {
+		score := &scores[i]
+		if requested == nil && strings.EqualFold(score.SheetName, requestedSheet) {
+			requested = score
+		}
+		if closest == nil || len(score.MissingHeaders) < len(closest.MissingHeaders) ||
+			(len(score.MissingHeaders) == len(closest.MissingHeaders) && score.Score > closest.Score) {
+			closest = score
+		}
+		if len(score.MissingHeaders) == 0 && (best == nil || score.Score > best.Score) {
+			best = score
+		}
+	}
+
+	switch {
+	case requested != nil && len(requested.MissingHeaders) == 0:
+		sourceFile.SheetName = requested.SheetName
+		return nil
+	case best != nil:
+		log.Printf("Sheet %q not usable, reading detected sheet %q matching %d headers", sourceFile.SheetName, best.SheetName, best.Score)
+		sourceFile.SheetName = best.SheetName
+		return nil
+	}
+
+	notFoundErr := &SheetNotFoundError{SheetName: sourceFile.SheetName, AvailableSheets: sourceFile.Reader.GetSheetList()}
+	switch {
+	case requested != nil:
+		notFoundErr.MissingHeaders = requested.MissingHeaders
+	case closest != nil:
+		notFoundErr.MissingHeaders = closest.MissingHeaders
+	default:
+		notFoundErr.MissingHeaders = svc.Cfg.GetRequiredColumns()
+	}
+	return notFoundErr
+}
+
+// ScoreSourceSheets scores the header of every sheet of the source file in sheet order, resolving the
+// configured column aliases.
+func (svc *SasaranImunisasiService) ScoreSourceSheets(reader SourceReader) ([]SourceSheetScore, error) {
+	expectedHeaders := svc.Cfg.GetExpectedSourceHeaders()
+	scores := []SourceSheetScore{}
+	for _, sheetName := range reader.GetSheetList() {
+		header, err := ReadSourceHeader(reader, sheetName)
+		if err != nil {
+			log.Printf("Error reading header of sheet %q: %v", sheetName, err)
+			return nil, err
+		}
+
+		sourceColumnMap := svc.GetSourceColumnMap(header)
+		score := SourceSheetScore{SheetName: sheetName, MissingHeaders: []string{}}
+		for _, name := range expectedHeaders {
+			if _, exists := sourceColumnMap[name]; exists {
+				score.Score++
+			}
+		}
+		for _, name := range svc.Cfg.GetRequiredColumns() {
+			if _, exists := sourceColumnMap[name]; !exists {
+				score.MissingHeaders = append(score.MissingHeaders, name)
+			}
+		}
+		scores = append(scores, score)
+	}
+	return scores, nil
+}
+
+// ReadSourceHeader returns the first non-blank row of the sheet, read as the header like ReadSourceFile does.
+// Returns nil when the sheet is empty.
+func ReadSourceHeader(reader SourceReader, sheetName string) ([]string, error) {
+	rows, err := reader.Rows(sheetName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rowIndex := 1; rows.Next(); rowIndex++ {
+		values, err := rows.Columns()
+		if err != nil {
+			return nil, err
+		}
+		if row := (SourceRow{Index: rowIndex, Values: values}); !row.IsBlank() {
+			return values, nil
+		}
+	}
+	return nil, rows.Error()
+}
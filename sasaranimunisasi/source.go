@@ -0,0 +1,358 @@
+package sasaranimunisasi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// SourceReader reads the rows of an uploaded source file sheet by sheet. XlsxReader and CsvReader
+// implement it so the same GenerateFile pipeline runs on both formats.
+type SourceReader interface {
+	Rows(sheetName string) (SourceRows, error)
+	GetSheetList() []string
+	Close() error
+}
+
+// SourceRows iterates over the rows of a source sheet in order, empty rows included, so the number of
+// calls to Next gives the row number.
+type SourceRows interface {
+	Next() bool
+	Columns() ([]string, error)
+	Error() error
+	Close() error
+}
+
+// SourceRow holds the values of a single source row, Index is the row number starting at 1.
+type SourceRow struct {
+	Index  int
+	Values []string
+}
+
+// GetValue retrieves the value of the row in the given column; returns "-" if the column is missing or the value is empty.
+func (row SourceRow) GetValue(column Column) string {
+	if column.Label == EMPTY_STRING || column.Index >= len(row.Values) || row.Values[column.Index] == EMPTY_STRING {
+		return HYPHEN
+	}
+	return row.Values[column.Index]
+}
+
+// consts for source file formats
+const (
+	FORMAT_XLSX = "xlsx"
+	FORMAT_CSV  = "csv"
+)
+
+// consts for the memory bounds of opening an xlsx source file
+const (
+	XLSX_UNZIP_SIZE_LIMIT     = 1 << 30  // workbooks unzipping past this size are rejected
+	XLSX_UNZIP_XML_SIZE_LIMIT = 16 << 20 // worksheets unzipping past this size are extracted to temporary files
+)
+
+// file signatures used to sniff the format of an uploaded source file
+var (
+	zipSignature = []byte("PK\x03\x04")
+	oleSignature = []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1") // legacy .xls workbook
+	utf8BOM      = []byte("\xEF\xBB\xBF")
+)
+
+// csvDelimiters holds the delimiters detected in CSV source files, in order of preference
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+// XlsxReader reads the cells of an xlsx source file. It implements SourceReader.
+type XlsxReader struct {
+	ExcelizeFile *excelize.File
+}
+
+// NewXlsxReader returns a SourceReader reading the given Excel file.
+func NewXlsxReader(excelizeFile *excelize.File) *XlsxReader {
+	return &XlsxReader{ExcelizeFile: excelizeFile}
+}
+
+// Rows returns the streaming row iterator of the sheet, yielding the raw cell values
+func (reader *XlsxReader) Rows(sheetName string) (SourceRows, error) {
+	rows, err := reader.ExcelizeFile.Rows(sheetName)
+	if err != nil {
+		return nil, err
+	}
+	return xlsxRows{rows}, nil
+}
+
+// xlsxRows adapts the excelize row iterator to SourceRows
+type xlsxRows struct {
+	*excelize.Rows
+}
+
+// Columns returns the raw cell values of the current row, so date cells are read as Excel serial numbers
+// whatever their number format
+func (rows xlsxRows) Columns() ([]string, error) {
+	return rows.Rows.Columns(excelize.Options{RawCellValue: true})
+}
+
+// GetSheetList returns the sheet names of the workbook
+func (reader *XlsxReader) GetSheetList() []string {
+	return reader.ExcelizeFile.GetSheetList()
+}
+
+// Close closes the workbook
+func (reader *XlsxReader) Close() error {
+	return reader.ExcelizeFile.Close()
+}
+
+// CsvReader reads the records of a CSV source file, decoded on the fly from the start of the source on every
+// call to Rows, so the file is never held in memory. A CSV file has a single sheet, so the sheet name is ignored.
+// It implements SourceReader.
+type CsvReader struct {
+	Source    io.ReadSeeker
+	Delimiter rune
+	Encoding  string
+}
+
+// NewCsvReader detects the encoding of the CSV source and the delimiter of its first lines.
+func NewCsvReader(source io.ReadSeeker) (*CsvReader, error) {
+	reader := &CsvReader{Source: source}
+	encoding, err := DetectCsvEncoding(source)
+	if err != nil {
+		return nil, err
+	}
+	reader.Encoding = encoding
+
+	decoded, err := reader.decode()
+	if err != nil {
+		return nil, err
+	}
+	lines := bufio.NewReader(decoded)
+	var head strings.Builder
+	for i := 0; i < 10; i++ {
+		line, err := lines.ReadString('\n')
+		head.WriteString(line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading csv: %w", err)
+		}
+	}
+	reader.Delimiter = DetectCsvDelimiter(head.String())
+	return reader, nil
+}
+
+// decode returns the content of the source from its start, decoded to UTF-8 from the detected encoding.
+func (reader *CsvReader) decode() (io.Reader, error) {
+	if _, err := reader.Source.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	switch reader.Encoding {
+	case CSV_ENCODING_UTF16:
+		return transform.NewReader(reader.Source, unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder()), nil
+	case CSV_ENCODING_WINDOWS_1252:
+		return transform.NewReader(reader.Source, charmap.Windows1252.NewDecoder()), nil
+	}
+	return transform.NewReader(reader.Source, unicode.UTF8BOM.NewDecoder()), nil
+}
+
+// Rows returns the row iterator of the CSV file, reading the records one by one. The sheet name is ignored.
+func (reader *CsvReader) Rows(_ string) (SourceRows, error) {
+	decoded, err := reader.decode()
+	if err != nil {
+		return nil, err
+	}
+	csvReader := csv.NewReader(decoded)
+	csvReader.Comma = reader.Delimiter
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	return &csvRows{reader: csvReader}, nil
+}
+
+// csvRows iterates over the records of a CSV file. It implements SourceRows.
+type csvRows struct {
+	reader *csv.Reader
+	record []string
+	err    error
+}
+
+// Next reads the next record, it returns false after the last record or on a read error
+func (rows *csvRows) Next() bool {
+	record, err := rows.reader.Read()
+	if err != nil {
+		if err != io.EOF {
+			rows.err = fmt.Errorf("reading csv: %w", err)
+		}
+		return false
+	}
+	rows.record = record
+	return true
+}
+
+// Columns returns the values of the current record
+func (rows *csvRows) Columns() ([]string, error) {
+	return rows.record, nil
+}
+
+// Error returns the error that stopped the iteration, if any
+func (rows *csvRows) Error() error {
+	return rows.err
+}
+
+// Close releases nothing, the source is closed by the reader
+func (rows *csvRows) Close() error {
+	return nil
+}
+
+// GetSheetList returns the single sheet of the CSV file
+func (reader *CsvReader) GetSheetList() []string {
+	return []string{SHEET_NAME}
+}
+
+// Close closes the source when it is a file
+func (reader *CsvReader) Close() error {
+	if closer, ok := reader.Source.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// DetectSourceFormat sniffs the format of an uploaded source file from its first bytes.
+// Legacy .xls workbooks are not supported.
+func DetectSourceFormat(head []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(head, zipSignature):
+		return FORMAT_XLSX, nil
+	case bytes.HasPrefix(head, oleSignature):
+		return EMPTY_STRING, fmt.Errorf("legacy .xls files are not supported, save the file as .xlsx or .csv")
+	}
+	return FORMAT_CSV, nil
+}
+
+// OpenSourceReader opens the file at the given path with the reader matching its sniffed format. CSV files are
+// read from the file handle record by record. Xlsx files are read whole into memory by excelize, compressed,
+// which rejects workbooks unzipping past XLSX_UNZIP_SIZE_LIMIT and extracts the worksheets unzipping past
+// XLSX_UNZIP_XML_SIZE_LIMIT to temporary files.
+func OpenSourceReader(path string) (SourceReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, len(oleSignature))
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+	format, err := DetectSourceFormat(head[:n])
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if format == FORMAT_CSV {
+		reader, err := NewCsvReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return reader, nil
+	}
+
+	file.Close()
+	excelFile, err := excelize.OpenFile(path, excelize.Options{
+		UnzipSizeLimit:    XLSX_UNZIP_SIZE_LIMIT,
+		UnzipXMLSizeLimit: XLSX_UNZIP_XML_SIZE_LIMIT,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewXlsxReader(excelFile), nil
+}
+
+// consts for the encodings of CSV source files
+const (
+	CSV_ENCODING_UTF8         = "UTF-8"
+	CSV_ENCODING_UTF16        = "UTF-16"
+	CSV_ENCODING_WINDOWS_1252 = "Windows-1252"
+)
+
+// DetectCsvEncoding returns the encoding of the CSV source, read up to its end without keeping it. UTF-8 and
+// UTF-16 are recognized by their byte order mark or a valid UTF-8 content, anything else is read as Windows-1252.
+func DetectCsvEncoding(source io.Reader) (string, error) {
+	content := bufio.NewReader(source)
+	head, err := content.Peek(len(utf8BOM))
+	if err != nil && err != io.EOF {
+		return EMPTY_STRING, fmt.Errorf("reading csv: %w", err)
+	}
+	switch {
+	case bytes.HasPrefix(head, utf8BOM):
+		return CSV_ENCODING_UTF8, nil
+	case bytes.HasPrefix(head, []byte("\xFF\xFE")), bytes.HasPrefix(head, []byte("\xFE\xFF")):
+		return CSV_ENCODING_UTF16, nil
+	}
+
+	for {
+		char, size, err := content.ReadRune()
+		if err == io.EOF {
+			return CSV_ENCODING_UTF8, nil
+		}
+		if err != nil {
+			return EMPTY_STRING, fmt.Errorf("reading csv: %w", err)
+		}
+		// a replacement character of one byte is an invalid UTF-8 sequence
+		if char == utf8.RuneError && size == 1 {
+			return CSV_ENCODING_WINDOWS_1252, nil
+		}
+	}
+}
+
+// DetectCsvDelimiter returns the delimiter splitting the first lines of the CSV content into the same
+// number of fields, preferring the delimiter found most often in the header. Defaults to a comma.
+func DetectCsvDelimiter(text string) rune {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) > 10 {
+		lines = lines[:10]
+	}
+
+	delimiter, bestConsistency, bestCount := csvDelimiters[0], 0, 0
+	for _, candidate := range csvDelimiters {
+		headerCount := countOutsideQuotes(lines[0], candidate)
+		if headerCount == 0 {
+			continue
+		}
+		consistency := 0
+		for _, line := range lines {
+			if countOutsideQuotes(line, candidate) == headerCount {
+				consistency++
+			}
+		}
+		if consistency > bestConsistency || (consistency == bestConsistency && headerCount > bestCount) {
+			delimiter, bestConsistency, bestCount = candidate, consistency, headerCount
+		}
+	}
+	return delimiter
+}
+
+// countOutsideQuotes counts the occurrences of the delimiter outside double quoted fields
+func countOutsideQuotes(line string, delimiter rune) int {
+	count, quoted := 0, false
+	for _, char := range line {
+		switch {
+		case char == '"':
+			quoted = !quoted
+		case char == delimiter && !quoted:
+			count++
+		}
+	}
+	return count
+}
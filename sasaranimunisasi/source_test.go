@@ -0,0 +1,258 @@
+package sasaranimunisasi
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestNewCsvReaderDetectsDelimiterAndEncoding(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		delimiter rune
+		encoding  string
+		want      string
+	}{
+		{
+			name:      "comma utf-8",
+			content:   "Nama Anak,Puskesmas\nJosé,\"Wanasari, Timur\"\n",
+			delimiter: ',',
+			encoding:  "UTF-8",
+			want:      "José",
+		},
+		{
+			name:      "semicolon utf-8 with bom",
+			content:   "\xEF\xBB\xBFNama Anak;Puskesmas\r\nJosé;\"Wanasari, Timur\"\r\n",
+			delimiter: ';',
+			encoding:  "UTF-8",
+			want:      "José",
+		},
+		{
+			name:      "semicolon windows-1252",
+			content:   "Nama Anak;Puskesmas\r\nJos\xE9;\"Wanasari; Timur\"\r\n",
+			delimiter: ';',
+			encoding:  "Windows-1252",
+			want:      "José",
+		},
+		{
+			name:      "semicolon utf-16",
+			content:   "\xFF\xFEN\x00a\x00m\x00a\x00 \x00A\x00n\x00a\x00k\x00;\x00P\x00u\x00s\x00k\x00e\x00s\x00m\x00a\x00s\x00\n\x00J\x00o\x00s\x00\xE9\x00;\x00W\x00a\x00n\x00a\x00s\x00a\x00r\x00i\x00\n\x00",
+			delimiter: ';',
+			encoding:  "UTF-16",
+			want:      "José",
+		},
+		{
+			name:      "tab",
+			content:   "Nama Anak\tPuskesmas\nJosé\tWanasari, Timur\n",
+			delimiter: '\t',
+			encoding:  "UTF-8",
+			want:      "José",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewCsvReader(strings.NewReader(tt.content))
+			if err != nil {
+				t.Fatalf("reading csv: %v", err)
+			}
+			if reader.Delimiter != tt.delimiter || reader.Encoding != tt.encoding {
+				t.Errorf("got delimiter %q encoding %s, want %q %s", reader.Delimiter, reader.Encoding, tt.delimiter, tt.encoding)
+			}
+			rows, _ := reader.Rows(SHEET_NAME)
+			var got [][]string
+			for rows.Next() {
+				values, _ := rows.Columns()
+				got = append(got, values)
+			}
+			if len(got) != 2 || len(got[1]) != 2 || got[0][0] != NAMA_ANAK || got[1][0] != tt.want {
+				t.Errorf("got rows %q, want %s and %s in column A", got, NAMA_ANAK, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectSourceFormat(t *testing.T) {
+	if format, _ := DetectSourceFormat([]byte("PK\x03\x04rest")); format != FORMAT_XLSX {
+		t.Errorf("got %q for a zip signature, want %q", format, FORMAT_XLSX)
+	}
+	if format, _ := DetectSourceFormat([]byte("Nama Anak;Puskesmas")); format != FORMAT_CSV {
+		t.Errorf("got %q for text content, want %q", format, FORMAT_CSV)
+	}
+	if _, err := DetectSourceFormat(oleSignature); err == nil {
+		t.Error("expected an error for a legacy xls file")
+	}
+}
+
+func TestOpenSourceReader(t *testing.T) {
+	dir := t.TempDir()
+	xlsxFile := excelize.NewFile()
+	xlsxFile.SetSheetRow(SHEET_NAME, "A1", &[]string{NAMA_ANAK, PUSKESMAS})
+	xlsxPath := filepath.Join(dir, "export.xlsx")
+	if err := xlsxFile.SaveAs(xlsxPath); err != nil {
+		t.Fatalf("saving xlsx: %v", err)
+	}
+	xlsxFile.Close()
+	csvPath, xlsPath := filepath.Join(dir, "export.csv"), filepath.Join(dir, "export.xls")
+	os.WriteFile(csvPath, []byte(NAMA_ANAK+";"+PUSKESMAS+"\n"), 0o644)
+	os.WriteFile(xlsPath, oleSignature, 0o644)
+
+	for _, path := range []string{xlsxPath, csvPath} {
+		reader, err := OpenSourceReader(path)
+		if err != nil {
+			t.Fatalf("%s: opening source: %v", path, err)
+		}
+		rows, err := reader.Rows(SHEET_NAME)
+		if err != nil {
+			t.Fatalf("%s: reading rows: %v", path, err)
+		}
+		rows.Next()
+		if columns, _ := rows.Columns(); strings.Join(columns, ",") != NAMA_ANAK+","+PUSKESMAS {
+			t.Errorf("%s: got header %v", path, columns)
+		}
+		rows.Close()
+		reader.Close()
+	}
+	if _, err := OpenSourceReader(xlsPath); err == nil {
+		t.Error("expected an error for a legacy xls file")
+	}
+}
+
+// TestGenerateFileFromCsv checks a semicolon separated export generates the same file as the xlsx export.
+func TestGenerateFileFromCsv(t *testing.T) {
+	cfg := loadTestConfig(t)
+	svc := newTestService(t, cfg)
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC) }
+
+	xlsxSource := newGoldenSourceXlsx(t, cfg, cfg.ImunisasiBayi)
+	defer xlsxSource.Reader.Close()
+	rows, err := xlsxSource.Reader.(*XlsxReader).ExcelizeFile.GetRows(SHEET_NAME)
+	if err != nil {
+		t.Fatalf("reading golden source: %v", err)
+	}
+
+	var content bytes.Buffer
+	writer := csv.NewWriter(&content)
+	writer.Comma = ';'
+	writer.WriteAll(rows)
+
+	reader, err := NewCsvReader(bytes.NewReader(content.Bytes()))
+	if err != nil {
+		t.Fatalf("reading csv: %v", err)
+	}
+	generatedFile, err := svc.GenerateFile(XlsxSourceFile{
+		Ctx:       context.WithValue(context.Background(), sasaranTypeKey, BAYI),
+		SheetName: SHEET_NAME,
+		Reader:    reader,
+	})
+	if err != nil {
+		t.Fatalf("generating file: %v", err)
+	}
+	defer generatedFile.ExcelizeFile.Close()
+
+	assertGolden(t, "bayi.golden", workbookToText(t, generatedFile.ExcelizeFile))
+}
+
+func TestSelectSourceSheet(t *testing.T) {
+	svc := newTestService(t, loadTestConfig(t))
+	newReader := func() *XlsxReader {
+		file := excelize.NewFile()
+		file.SetSheetRow(SHEET_NAME, "A1", &[]string{"Rekap", "Jumlah"})
+		file.NewSheet("Data Bayi")
+		file.SetSheetRow("Data Bayi", "A2", &[]string{NAMA_ANAK, TANGGAL_LAHIR_ANAK, "Status Imunisasi HB0"})
+		file.NewSheet("Tanpa Tanggal")
+		file.SetSheetRow("Tanpa Tanggal", "A1", &[]string{NAMA_ANAK, NAMA_ORANG_TUA, PUSKESMAS, "Status Imunisasi HB0"})
+		return NewXlsxReader(file)
+	}
+
+	tests := []struct {
+		name      string
+		sheetName string
+		want      string
+	}{
+		{name: "omitted", sheetName: EMPTY_STRING, want: "Data Bayi"},
+		{name: "other case", sheetName: " data bayi", want: "Data Bayi"},
+		{name: "misspelled", sheetName: "Data Bayii", want: "Data Bayi"},
+		{name: "missing required headers", sheetName: "Tanpa Tanggal", want: "Data Bayi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceFile := &XlsxSourceFile{SheetName: tt.sheetName, Reader: newReader()}
+			defer sourceFile.Reader.Close()
+			if err := svc.SelectSourceSheet(sourceFile); err != nil {
+				t.Fatalf("selecting sheet: %v", err)
+			}
+			if sourceFile.SheetName != tt.want {
+				t.Errorf("got sheet %q, want %q", sourceFile.SheetName, tt.want)
+			}
+		})
+	}
+
+	t.Run("no matching sheet", func(t *testing.T) {
+		file := excelize.NewFile()
+		file.SetSheetRow(SHEET_NAME, "A1", &[]string{NAMA_ANAK, "Alamat"})
+		sourceFile := &XlsxSourceFile{SheetName: "Data", Reader: NewXlsxReader(file)}
+		defer sourceFile.Reader.Close()
+
+		var notFoundErr *SheetNotFoundError
+		if err := svc.SelectSourceSheet(sourceFile); !errors.As(err, &notFoundErr) {
+			t.Fatalf("got error %v, want SheetNotFoundError", err)
+		}
+		if strings.Join(notFoundErr.AvailableSheets, ",") != SHEET_NAME || strings.Join(notFoundErr.MissingHeaders, ",") != TANGGAL_LAHIR_ANAK {
+			t.Errorf("got available sheets %v and missing headers %v", notFoundErr.AvailableSheets, notFoundErr.MissingHeaders)
+		}
+	})
+}
+
+func TestReadSourceFileResolvesColumnAliases(t *testing.T) {
+	svc := newTestService(t, loadTestConfig(t))
+	ctx := context.WithValue(context.WithValue(context.Background(), sasaranTypeKey, BAYI),
+		tanggalAcuanKey, time.Date(2024, time.October, 3, 0, 0, 0, 0, time.UTC))
+
+	t.Run("aliased headers", func(t *testing.T) {
+		file := excelize.NewFile()
+		defer file.Close()
+		file.SetSheetRow(SHEET_NAME, "A1", &[]string{"  nama   BAYI ", "Tgl Lahir", "Nama Ibu", "tgl imunisasi HB0", "Status Imunisasi HB0"})
+		file.SetSheetRow(SHEET_NAME, "A2", &[]string{"Ahmad", "2024-03-02", "Ibu Ahmad", "2024-03-03", "ideal"})
+
+		fileGeneration, err := svc.ReadSourceFile(XlsxSourceFile{Ctx: ctx, SheetName: SHEET_NAME, Reader: NewXlsxReader(file)})
+		if err != nil {
+			t.Fatalf("reading source file: %v", err)
+		}
+		list := fileGeneration.Generations[0].SasaranImunisasiList
+		if len(list) != 1 || list[0].NamaAnak != "Ahmad" || list[0].NamaOrangTua != "Ibu Ahmad" {
+			t.Fatalf("got sasaran imunisasi %+v, want Ahmad with Ibu Ahmad", list)
+		}
+		if got := list[0].DetailImunisasi["HB0"].Tanggal["Tanggal Imunisasi HB0"]; !got.Equal(time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("got tanggal imunisasi HB0 %q, want 2024-03-03", got)
+		}
+	})
+
+	t.Run("missing required columns", func(t *testing.T) {
+		file := excelize.NewFile()
+		defer file.Close()
+		file.SetSheetRow(SHEET_NAME, "A1", &[]string{"Nama", NAMA_ORANG_TUA})
+		file.SetSheetRow(SHEET_NAME, "A2", &[]string{"Ahmad", "Ibu Ahmad"})
+
+		_, err := svc.ReadSourceFile(XlsxSourceFile{Ctx: ctx, SheetName: SHEET_NAME, Reader: NewXlsxReader(file)})
+		var missingErr *MissingColumnsError
+		if !errors.As(err, &missingErr) {
+			t.Fatalf("got error %v, want MissingColumnsError", err)
+		}
+		if strings.Join(missingErr.MissingColumns, ",") != NAMA_ANAK+","+TANGGAL_LAHIR_ANAK {
+			t.Errorf("got missing columns %v", missingErr.MissingColumns)
+		}
+		if !strings.Contains(err.Error(), "Tanggal Lahir Anak (Tanggal Lahir Anak / Tgl Lahir") {
+			t.Errorf("error does not list the accepted headers: %v", err)
+		}
+	})
+}
@@ -0,0 +1,251 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// consts for the status columns of the generated sheet
+const (
+	STATUS_LABEL     = "label" // status written as Sudah, Belum or Terlambat
+	STATUS_ANGKA     = "angka" // status written as the source value, ideal = 0 or non-ideal = 1, for further analysis
+	STATUS_SUDAH     = "Sudah"
+	STATUS_BELUM     = "Belum"
+	STATUS_TERLAMBAT = "Terlambat"
+	KETERANGAN       = "Keterangan"
+)
+
+// consts for the fill colors of the status cells
+const (
+	WARNA_HIJAU  = "hijau"  // ideal
+	WARNA_KUNING = "kuning" // due soon
+	WARNA_MERAH  = "merah"  // non-ideal
+)
+
+// statusColors holds the fill and font colors of the status cells by color, the Excel good, neutral and bad colors
+var statusColors = map[string][2]string{
+	WARNA_HIJAU:  {"#C6EFCE", "#006100"},
+	WARNA_KUNING: {"#FFEB9C", "#9C5700"},
+	WARNA_MERAH:  {"#FFC7CE", "#9C0006"},
+}
+
+// StatusLegend represents a value of the status cells in one of its colors, explained in the legend.
+type StatusLegend struct {
+	Value      string
+	Warna      string // fill color of the value, empty when uncolored
+	Keterangan string
+}
+
+// ValidateStatusImunisasi validates the requested status mode, an empty value means the configured mode.
+func ValidateStatusImunisasi(statusImunisasi string) (string, error) {
+	statusImunisasi = strings.ToLower(strings.TrimSpace(statusImunisasi))
+	switch statusImunisasi {
+	case EMPTY_STRING, STATUS_LABEL, STATUS_ANGKA:
+		return statusImunisasi, nil
+	}
+	return EMPTY_STRING, fmt.Errorf("invalid statusImunisasi %q, accepted values: %s, %s", statusImunisasi, STATUS_LABEL, STATUS_ANGKA)
+}
+
+// IsStatusLabel reports whether the status columns of the generated sheet hold labels instead of numbers.
+func (gen *SasaranImunisasiGeneration) IsStatusLabel() bool {
+	return gen.StatusImunisasi != STATUS_ANGKA
+}
+
+// GetStatusLabel returns the status label of an antigen of the child: Terlambat when its recommended age window
+// has passed, Belum when it is otherwise non-ideal or not due yet and Sudah when it is given.
+func (sasaranImunisasi *SasaranImunisasi) GetStatusLabel(imun string) string {
+	status := sasaranImunisasi.StatusJadwal[imun]
+	switch {
+	case status == JADWAL_TERLAMBAT:
+		return STATUS_TERLAMBAT
+	case status == JADWAL_BELUM_WAKTUNYA || sasaranImunisasi.IsImunisasiNonIdeal(imun):
+		return STATUS_BELUM
+	}
+	return STATUS_SUDAH
+}
+
+// IsImunisasiSegera reports whether an antigen of the child not due yet becomes due within hari_segera days of
+// the reference date.
+func (gen *SasaranImunisasiGeneration) IsImunisasiSegera(sasaranImunisasi SasaranImunisasi, imun string) bool {
+	if gen.Cfg.HariSegera == 0 || sasaranImunisasi.StatusJadwal[imun] != JADWAL_BELUM_WAKTUNYA {
+		return false
+	}
+	batasSegera := gen.TanggalAcuan.AddDate(0, 0, gen.Cfg.HariSegera)
+	for _, jadwal := range gen.JadwalImunisasi {
+		if jadwal.Imunisasi == imun {
+			return !jadwal.Mulai.AddTo(sasaranImunisasi.TanggalLahirAnak).After(batasSegera)
+		}
+	}
+	return false
+}
+
+// GetStatusColumns returns the status column of every antigen of the generated sheet, e.g. "Status Imunisasi HB0",
+// by antigen.
+func (gen *SasaranImunisasiGeneration) GetStatusColumns() map[string]string {
+	statusColumns := make(map[string]string)
+	for _, imun := range gen.Imunisasi {
+		for _, name := range gen.Cfg.GetDetailColumnNames(imun) {
+			if _, exists := gen.SasaranColumnMap[name]; exists && strings.HasPrefix(name, STATUS) {
+				statusColumns[imun] = name
+			}
+		}
+	}
+	return statusColumns
+}
+
+// GetStatusLegend returns the values of the status cells in each of their colors, explained in the legend. In the
+// label mode Belum is red when the antigen is non-ideal, amber when it is due within hari_segera days and
+// uncolored otherwise.
+func (gen *SasaranImunisasiGeneration) GetStatusLegend() []StatusLegend {
+	if !gen.IsStatusLabel() {
+		return []StatusLegend{
+			{"0", WARNA_HIJAU, "ideal, sudah diberikan"},
+			{"1", WARNA_MERAH, "tidak ideal, belum diberikan"},
+		}
+	}
+
+	legend := []StatusLegend{
+		{STATUS_SUDAH, WARNA_HIJAU, "sudah diberikan"},
+		{STATUS_BELUM, WARNA_MERAH, "sudah waktunya, belum diberikan"},
+	}
+	if gen.Cfg.HariSegera > 0 {
+		legend = append(legend, StatusLegend{STATUS_BELUM, WARNA_KUNING, fmt.Sprintf("waktunya dalam %d hari", gen.Cfg.HariSegera)})
+	}
+	return append(legend,
+		StatusLegend{STATUS_BELUM, EMPTY_STRING, "belum waktunya"},
+		StatusLegend{STATUS_TERLAMBAT, WARNA_MERAH, "melewati batas usia"},
+	)
+}
+
+// SetStatusLegend writes the legend of the status colors in the row between the title and the header: every
+// value in its color, followed by their meanings in a single cell overflowing to the right. A value shown in
+// several colors is explained with its color, e.g. "Belum (merah)".
+func (gen *SasaranImunisasiGeneration) SetStatusLegend(newFile NewXlsxFile) {
+	if gen.IsRingkas() || len(gen.GetStatusColumns()) == 0 {
+		return
+	}
+
+	legend := gen.GetStatusLegend()
+	valueCount := make(map[string]int)
+	for _, status := range legend {
+		valueCount[status.Value]++
+	}
+
+	cells := []interface{}{excelize.Cell{StyleID: newFile.HeaderStyle, Value: KETERANGAN}}
+	meanings := []string{}
+	for _, status := range legend {
+		styleID, name := newFile.BodyStyle, status.Value
+		if status.Warna != EMPTY_STRING {
+			colors := statusColors[status.Warna]
+			style := getXlsxStyle(false)
+			style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{colors[0]}}
+			style.Font.Color = colors[1]
+			var err error
+			if styleID, err = newFile.ExcelizeFile.NewStyle(style); err != nil {
+				log.Printf("Error creating style for status legend: %v", err)
+			}
+			if valueCount[status.Value] > 1 {
+				name += " (" + status.Warna + ")"
+			}
+		}
+		cells = append(cells, excelize.Cell{StyleID: styleID, Value: status.Value})
+		meanings = append(meanings, name+": "+status.Keterangan)
+	}
+	cells = append(cells, strings.Join(meanings, "; "))
+	if err := newFile.StreamWriter.SetRow(A+strconv.Itoa(newFile.TitleRowAt+1), cells); err != nil {
+		log.Printf("Error writing status legend of sheet %s: %v", newFile.SheetName, err)
+	}
+}
+
+// SetStatusFormatting colors the status cells of the body with conditional formats, so the colors follow the
+// values when the cadres edit them. Sudah and Terlambat, or 0 and 1 in the angka mode, are colored by value over
+// the whole column. Belum is colored red on the non-ideal cells and amber on the cells due soon only, by a
+// format applied to these cells.
+func (gen *SasaranImunisasiGeneration) SetStatusFormatting(newFile NewXlsxFile) {
+	if gen.IsRingkas() || len(gen.SasaranImunisasiList) == 0 {
+		return
+	}
+
+	formatIDs := make(map[string]int)
+	for _, warna := range []string{WARNA_HIJAU, WARNA_KUNING, WARNA_MERAH} {
+		colors := statusColors[warna]
+		formatID, err := newFile.ExcelizeFile.NewConditionalStyle(&excelize.Style{
+			Font: &excelize.Font{Color: colors[1]},
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{colors[0]}},
+		})
+		if err != nil {
+			log.Printf("Error creating conditional style %s: %v", warna, err)
+			return
+		}
+		formatIDs[warna] = formatID
+	}
+	newFormat := func(value, warna string) excelize.ConditionalFormatOptions {
+		if gen.IsStatusLabel() {
+			value = strconv.Quote(value)
+		}
+		return excelize.ConditionalFormatOptions{Type: "cell", Criteria: "==", Format: formatIDs[warna], Value: value}
+	}
+
+	valueFormats := []excelize.ConditionalFormatOptions{newFormat("0", WARNA_HIJAU), newFormat("1", WARNA_MERAH)}
+	if gen.IsStatusLabel() {
+		valueFormats = []excelize.ConditionalFormatOptions{newFormat(STATUS_SUDAH, WARNA_HIJAU), newFormat(STATUS_TERLAMBAT, WARNA_MERAH)}
+	}
+	setFormat := func(rangeRef string, formats []excelize.ConditionalFormatOptions) {
+		if err := newFile.ExcelizeFile.SetConditionalFormat(newFile.SheetName, rangeRef, formats); err != nil {
+			log.Printf("Error setting conditional format of sheet %s: %v", newFile.SheetName, err)
+		}
+	}
+
+	lastRow := strconv.Itoa(newFile.StartBodyRowAt + len(gen.SasaranImunisasiList) - 1)
+	statusColumns := gen.GetStatusColumns()
+	for _, imun := range gen.Imunisasi {
+		name, exists := statusColumns[imun]
+		if !exists {
+			continue
+		}
+		label := gen.SasaranColumnMap[name].Label
+		setFormat(label+strconv.Itoa(newFile.StartBodyRowAt)+":"+label+lastRow, valueFormats)
+		if !gen.IsStatusLabel() {
+			continue
+		}
+
+		nonIdealRows, segeraRows := []int{}, []int{}
+		for i, sasaranImunisasi := range gen.SasaranImunisasiList {
+			switch {
+			case sasaranImunisasi.IsImunisasiNonIdeal(imun):
+				nonIdealRows = append(nonIdealRows, newFile.StartBodyRowAt+i)
+			case gen.IsImunisasiSegera(sasaranImunisasi, imun):
+				segeraRows = append(segeraRows, newFile.StartBodyRowAt+i)
+			}
+		}
+		if len(nonIdealRows) > 0 {
+			setFormat(GetColumnRangeRef(label, nonIdealRows), []excelize.ConditionalFormatOptions{newFormat(STATUS_BELUM, WARNA_MERAH)})
+		}
+		if len(segeraRows) > 0 {
+			setFormat(GetColumnRangeRef(label, segeraRows), []excelize.ConditionalFormatOptions{newFormat(STATUS_BELUM, WARNA_KUNING)})
+		}
+	}
+}
+
+// GetColumnRangeRef returns the reference of the given ascending rows of a column, consecutive rows joined in
+// a single range, e.g. "K5:K7 K9".
+func GetColumnRangeRef(label string, rows []int) string {
+	ranges := []string{}
+	for i := 0; i < len(rows); {
+		j := i
+		for j+1 < len(rows) && rows[j+1] == rows[j]+1 {
+			j++
+		}
+		rangeRef := label + strconv.Itoa(rows[i])
+		if j > i {
+			rangeRef += ":" + label + strconv.Itoa(rows[j])
+		}
+		ranges = append(ranges, rangeRef)
+		i = j + 1
+	}
+	return strings.Join(ranges, SPACE)
+}
@@ -0,0 +1,117 @@
+package sasaranimunisasi
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetStatusLabel(t *testing.T) {
+	sasaranImunisasi := SasaranImunisasi{
+		StatusJadwal: map[string]StatusJadwal{
+			"HB0":     JADWAL_SUDAH,
+			"BCG 1":   JADWAL_TERLAMBAT,
+			"POLIO 1": JADWAL_JATUH_TEMPO,
+			"POLIO 2": JADWAL_BELUM_WAKTUNYA,
+		},
+		DetailImunisasi: map[string]DetailImunisasi{
+			IDL_1: {Status: map[string]int{STATUS_IDL_1: 0}},
+		},
+	}
+	tests := map[string]string{
+		"HB0":     STATUS_SUDAH,
+		"BCG 1":   STATUS_TERLAMBAT,
+		"POLIO 1": STATUS_BELUM,
+		"POLIO 2": STATUS_BELUM,
+		IDL_1:     STATUS_SUDAH,
+		IBL_1:     STATUS_BELUM,
+	}
+	for imun, want := range tests {
+		if got := sasaranImunisasi.GetStatusLabel(imun); got != want {
+			t.Errorf("GetStatusLabel(%q) = %q, want %q", imun, got, want)
+		}
+	}
+}
+
+func TestIsImunisasiSegera(t *testing.T) {
+	cfg := loadTestConfig(t)
+	cfg.HariSegera = 14
+	svc := newTestService(t, cfg)
+	gen := svc.NewGeneration(BAYI, date(2024, time.October, 3))
+
+	// born on Aug 10, POLIO 2 and DPT-Hb-Hib 1 are due from Oct 10, within 14 days, and MR 1 from May 10
+	sasaranImunisasi := SasaranImunisasi{TanggalLahirAnak: date(2024, time.August, 10)}
+	sasaranImunisasi.SetJadwalImunisasi(gen.JadwalImunisasi, gen.TanggalAcuan)
+	tests := map[string]bool{
+		"HB0":          false, // overdue
+		"POLIO 2":      true,
+		"DPT-Hb-Hib 1": true,
+		"MR 1":         false,
+	}
+	for imun, want := range tests {
+		if got := gen.IsImunisasiSegera(sasaranImunisasi, imun); got != want {
+			t.Errorf("IsImunisasiSegera(%q) = %v, want %v", imun, got, want)
+		}
+	}
+
+	gen.Cfg.HariSegera = 0
+	if gen.IsImunisasiSegera(sasaranImunisasi, "POLIO 2") {
+		t.Errorf("got POLIO 2 due soon with hari_segera 0")
+	}
+}
+
+func TestGetColumnRangeRef(t *testing.T) {
+	if got, want := GetColumnRangeRef("K", []int{5, 6, 7, 9, 11, 12}), "K5:K7 K9 K11:K12"; got != want {
+		t.Errorf("got range %q, want %q", got, want)
+	}
+}
+
+func TestGenerateFileStatusImunisasi(t *testing.T) {
+	cfg := loadTestConfig(t)
+	svc := newTestService(t, cfg)
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC) }
+	belumFormat := regexp.MustCompile(`dxfId="(\d+)"[^>]*><formula>&#34;Belum&#34;`)
+
+	for _, test := range []struct {
+		statusImunisasi string
+		want            []string
+		belumColors     int // colors of Belum, red when non-ideal and amber when due soon
+	}{
+		{STATUS_LABEL, []string{"Keterangan\tSudah\tBelum\tBelum\tBelum\tTerlambat\tSudah: sudah diberikan; Belum (merah): sudah waktunya, belum diberikan; Belum (kuning): waktunya dalam 14 hari; Belum: belum waktunya", `&#34;Sudah&#34;`}, 2},
+		{STATUS_ANGKA, []string{"Keterangan\t0\t1\t0: ideal", "<formula>1</formula>"}, 0},
+	} {
+		sourceFile := newGoldenSourceXlsx(t, cfg, cfg.ImunisasiBayi)
+		ctx := context.WithValue(context.Background(), sasaranTypeKey, BAYI)
+		sourceFile.Ctx = context.WithValue(ctx, statusImunisasiKey, test.statusImunisasi)
+		generatedFile, err := svc.GenerateFile(sourceFile)
+		sourceFile.Reader.Close()
+		if err != nil {
+			t.Fatalf("%s: generating file: %v", test.statusImunisasi, err)
+		}
+
+		// the legend row and the conditional formats of the status columns
+		got := sheetToText(t, generatedFile.ExcelizeFile, SHEET_NAME)
+		if !strings.Contains(got, test.want[0]) {
+			t.Errorf("%s: got sheet\n%s\nwant legend %q", test.statusImunisasi, got, test.want[0])
+		}
+		xml := sheetXml(t, generatedFile.ExcelizeFile)
+		if bytes.Count(xml, []byte("<conditionalFormatting")) < len(cfg.ImunisasiBayi) || !bytes.Contains(xml, []byte(test.want[1])) {
+			t.Errorf("%s: got no conditional format %s on every status column", test.statusImunisasi, test.want[1])
+		}
+		if bytes.Contains(xml, []byte("Jatuh Tempo")) {
+			t.Errorf("%s: got a Jatuh Tempo conditional format, want only Sudah, Belum and Terlambat", test.statusImunisasi)
+		}
+
+		// Belum is colored red on the non-ideal cells and amber on the cells due soon
+		belumColors := make(map[string]bool)
+		for _, match := range belumFormat.FindAllSubmatch(xml, -1) {
+			belumColors[string(match[1])] = true
+		}
+		if len(belumColors) != test.belumColors {
+			t.Errorf("%s: got Belum in %d colors, want %d", test.statusImunisasi, len(belumColors), test.belumColors)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package sasaranimunisasi
+
+import (
+	"strconv"
+	"strings"
+)
+
+// defaultFooterKeywords holds the words starting a total or footer row of a source export when
+// footer_keywords is not configured
+var defaultFooterKeywords = []string{"total", "jumlah", "grand total", "rata-rata", "keterangan", "catatan", "dicetak", "sumber"}
+
+// TableBoundary tracks the boundary of the source table while its rows are streamed. Blank rows are only
+// counted as skipped when a data row follows them, so the blank rows after the last data row, e.g. formatted
+// but empty rows, mark the end of the table instead of being reported.
+type TableBoundary struct {
+	HeaderRow   int
+	LastRow     int // last data row of the table
+	ColumnCount int // number of columns of the header row, up to the last non-empty header cell
+	BlankRows   int
+	FooterRows  int
+
+	pendingBlankRows int
+}
+
+// SetHeader records the header row of the table.
+func (boundary *TableBoundary) SetHeader(row SourceRow) {
+	boundary.HeaderRow = row.Index
+	for i, value := range row.Values {
+		if strings.TrimSpace(value) != EMPTY_STRING {
+			boundary.ColumnCount = i + 1
+		}
+	}
+}
+
+// AddBlankRow records a blank row after the header.
+func (boundary *TableBoundary) AddBlankRow() {
+	boundary.pendingBlankRows++
+}
+
+// AddFooterRow records a total or footer row after the header, wherever it is.
+func (boundary *TableBoundary) AddFooterRow() {
+	boundary.FooterRows++
+}
+
+// AddDataRow records a data row, counting the blank rows seen since the previous data row as skipped.
+func (boundary *TableBoundary) AddDataRow(rowIndex int) {
+	boundary.LastRow = rowIndex
+	boundary.BlankRows += boundary.pendingBlankRows
+	boundary.pendingBlankRows = 0
+}
+
+// GetRange returns the cell range of the table from the header to the last data row, e.g. "A1:BH250".
+// It returns an empty string when no header was found.
+func (boundary *TableBoundary) GetRange() string {
+	if boundary.HeaderRow == 0 {
+		return EMPTY_STRING
+	}
+	lastRow := max(boundary.LastRow, boundary.HeaderRow)
+	return A + strconv.Itoa(boundary.HeaderRow) + ":" + GetXlsxColumnLabel(max(boundary.ColumnCount, 1)) + strconv.Itoa(lastRow)
+}
+
+// IsBlank reports whether every value of the row is empty or whitespace.
+func (row SourceRow) IsBlank() bool {
+	for _, value := range row.Values {
+		if strings.TrimSpace(value) != EMPTY_STRING {
+			return false
+		}
+	}
+	return true
+}
+
+// GetFooterKeywords returns the configured footer keywords, or the default ones when none is configured.
+func (cfg *SasaranImunisasiConfig) GetFooterKeywords() []string {
+	if len(cfg.FooterKeywords) > 0 {
+		return cfg.FooterKeywords
+	}
+	return defaultFooterKeywords
+}
+
+// IsFooterRow reports whether the row is a total or footer row: its first non-empty value starts with a
+// footer keyword and it has no birth date, so a child whose name starts with a keyword is still read.
+func (cfg *SasaranImunisasiConfig) IsFooterRow(row SourceRow, sourceColumnMap map[string]Column) bool {
+	if column, exists := sourceColumnMap[TANGGAL_LAHIR_ANAK]; exists && row.GetValue(column) != HYPHEN {
+		return false
+	}
+
+	for _, value := range row.Values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == EMPTY_STRING {
+			continue
+		}
+		for _, keyword := range cfg.GetFooterKeywords() {
+			if strings.HasPrefix(value, strings.ToLower(keyword)) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// consts for reading and writing dates
+const (
+	DATE_FORMAT      = "02-01-2006" // format of the dates written as text, e.g. in Imunisasi Berikutnya
+	DATE_NUMBER_FMT  = "dd-mm-yyyy" // number format of the date cells of the generated file
+	MIN_EXCEL_SERIAL = 1            // serial number of 1 January 1900
+	MAX_EXCEL_SERIAL = 2958465      // serial number of 31 December 9999, the last date Excel supports
+)
+
+// defaultDateLayouts holds the layouts tried in order when date_layouts is not configured. Day-first layouts
+// come before month-first ones, as in Indonesian exports.
+var defaultDateLayouts = []string{
+	"2006-1-2",
+	"2/1/2006",
+	"2-1-2006",
+	"2.1.2006",
+	"2006/1/2",
+	"2 January 2006",
+	"2 Jan 2006",
+	"2-Jan-2006",
+	"2006-1-2 15:04:05",
+	"2006-1-2 15:04",
+	"2006-1-2T15:04:05Z07:00",
+	"2006-1-2T15:04:05",
+	"2/1/2006 15:04:05",
+	"2/1/2006 15:04",
+}
+
+// indonesianMonths maps the lower-case Indonesian month names and abbreviations to the English month names
+// understood by time.Parse
+var indonesianMonths = map[string]string{
+	"januari": "January", "februari": "February", "pebruari": "February", "maret": "March", "april": "April",
+	"mei": "May", "juni": "June", "juli": "July", "agustus": "August", "september": "September",
+	"oktober": "October", "november": "November", "nopember": "November", "desember": "December",
+	"jan": "January", "feb": "February", "peb": "February", "mar": "March", "apr": "April", "jun": "June",
+	"jul": "July", "agu": "August", "agt": "August", "ags": "August", "agst": "August", "sep": "September",
+	"sept": "September", "okt": "October", "nov": "November", "nop": "November", "des": "December",
+}
+
+// GetDateLayouts returns the configured date layouts, or the default ones when none is configured.
+func (cfg *SasaranImunisasiConfig) GetDateLayouts() []string {
+	if len(cfg.DateLayouts) > 0 {
+		return cfg.DateLayouts
+	}
+	return defaultDateLayouts
+}
+
+// ParseTanggal parses a date read from the source file: an Excel serial number, a value matching one of the
+// date layouts, or a date written with Indonesian month names such as "2 Januari 2024". The time of day of
+// timestamps is dropped, the returned date is midnight UTC.
+func (cfg *SasaranImunisasiConfig) ParseTanggal(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == EMPTY_STRING || value == HYPHEN {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+
+	if serial, err := strconv.ParseFloat(value, 64); err == nil {
+		if serial < MIN_EXCEL_SERIAL || serial > MAX_EXCEL_SERIAL {
+			return time.Time{}, fmt.Errorf("date %q is not a valid Excel serial number", value)
+		}
+		date, err := excelize.ExcelDateToTime(serial, false)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("date %q is not a valid Excel serial number: %w", value, err)
+		}
+		return ToDate(date), nil
+	}
+
+	value = ReplaceIndonesianMonths(value)
+	for _, layout := range cfg.GetDateLayouts() {
+		if date, err := time.Parse(layout, value); err == nil {
+			return ToDate(date), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+// ReplaceIndonesianMonths replaces the Indonesian month names and abbreviations of a date with English ones,
+// e.g. "2 Januari 2024" becomes "2 January 2024".
+func ReplaceIndonesianMonths(value string) string {
+	words := strings.Fields(value)
+	for i, word := range words {
+		if month, exists := indonesianMonths[strings.ToLower(strings.TrimSuffix(word, "."))]; exists {
+			words[i] = month
+		}
+	}
+
+	// month abbreviations may also be separated by hyphens, e.g. "2-Jan-2024"
+	parts := strings.Split(strings.Join(words, SPACE), "-")
+	for i, part := range parts {
+		if month, exists := indonesianMonths[strings.ToLower(part)]; exists {
+			parts[i] = month[:3]
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// ToDate returns the calendar date of the given time at midnight UTC.
+func ToDate(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
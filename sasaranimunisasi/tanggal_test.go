@@ -0,0 +1,82 @@
+package sasaranimunisasi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestParseTanggal(t *testing.T) {
+	cfg := loadTestConfig(t)
+	want := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+
+	for _, value := range []string{
+		"2024-01-02",
+		"45293",
+		"45293.75",
+		"02/01/2024",
+		"2/1/2024",
+		"02-01-2024",
+		"2 Januari 2024",
+		"2 januari 2024",
+		"02 Jan 2024",
+		"2-Jan-2024",
+		"2 Jan. 2024",
+		"2024-01-02 13:45:00",
+		"2024-01-02T13:45:00+07:00",
+		" 2024-01-02 ",
+	} {
+		got, err := cfg.ParseTanggal(value)
+		if err != nil {
+			t.Errorf("parsing %q: %v", value, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("parsing %q: got %v, want %v", value, got, want)
+		}
+	}
+
+	for _, value := range []string{EMPTY_STRING, HYPHEN, "belum", "0", "31/31/2024", "2 Foo 2024"} {
+		if got, err := cfg.ParseTanggal(value); err == nil {
+			t.Errorf("parsing %q: got %v, want an error", value, got)
+		}
+	}
+}
+
+// TestReadSourceFileDateCells checks date cells of the source are read whatever their number format and
+// written as date cells in the format dd-mm-yyyy.
+func TestReadSourceFileDateCells(t *testing.T) {
+	cfg := loadTestConfig(t)
+	svc := newTestService(t, cfg)
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC) }
+
+	file := excelize.NewFile()
+	defer file.Close()
+	file.SetSheetRow(SHEET_NAME, "A1", &[]string{NAMA_ANAK, TANGGAL_LAHIR_ANAK, "Tanggal Imunisasi HB0", "Status Imunisasi HB0"})
+	file.SetSheetRow(SHEET_NAME, "A2", &[]interface{}{"Ahmad", time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC), "3 Maret 2024", "ideal"})
+	usStyle, _ := file.NewStyle(&excelize.Style{NumFmt: 14})
+	file.SetCellStyle(SHEET_NAME, "B2", "B2", usStyle)
+
+	generatedFile, err := svc.GenerateFile(XlsxSourceFile{
+		Ctx:       context.WithValue(context.Background(), sasaranTypeKey, BAYI),
+		SheetName: SHEET_NAME,
+		Reader:    NewXlsxReader(file),
+	})
+	if err != nil {
+		t.Fatalf("generating file: %v", err)
+	}
+	defer generatedFile.ExcelizeFile.Close()
+
+	sasaranColumnMap := svc.SasaranBayiColumnMap
+	for name, want := range map[string]string{TANGGAL_LAHIR_ANAK: "02-03-2024", "Tanggal Imunisasi HB0": "03-03-2024"} {
+		cell := sasaranColumnMap[name].Label + "4"
+		if got, _ := generatedFile.ExcelizeFile.GetCellValue(SHEET_NAME, cell); got != want {
+			t.Errorf("got %s %q, want %q", name, got, want)
+		}
+		if cellType, _ := generatedFile.ExcelizeFile.GetCellType(SHEET_NAME, cell); cellType == excelize.CellTypeSharedString || cellType == excelize.CellTypeInlineString {
+			t.Errorf("got %s written as text, want a date cell", name)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// consts for the invitation slips (surat undangan)
+const (
+	UNDANGAN_JUDUL          = "Undangan Imunisasi"
+	UNDANGAN_SLIP_PER_PAGE  = 6
+	UNDANGAN_TANGGAL_KOSONG = "........................................" // left blank to be written by hand
+)
+
+// undanganSlipLayouts holds the columns and rows of the slips of an A4 page by number of slips per page
+var undanganSlipLayouts = map[int][2]int{2: {1, 2}, 4: {2, 2}, 6: {2, 3}, 8: {2, 4}}
+
+// UndanganConfig holds the content and layout of the invitation slips handed to the parents before the
+// posyandu day.
+type UndanganConfig struct {
+	Judul          string `yaml:"judul"`
+	Tanggal        string `yaml:"tanggal"` // posyandu day in the format "YYYY-MM-DD", defaults to the next pengingat posyandu day
+	Jam            string `yaml:"jam"`
+	Tempat         string `yaml:"tempat"` // defaults to the posyandu of the child
+	Pesan          string `yaml:"pesan"`
+	Penandatangan  string `yaml:"penandatangan"`
+	SlipPerHalaman int    `yaml:"slip_per_halaman"` // slips per A4 page: 2, 4, 6 or 8
+
+	tanggal time.Time
+}
+
+// Undangan represents the invitation slip of a child, addressed to their parent.
+type Undangan struct {
+	NamaAnak            string
+	NamaOrangTua        string
+	UsiaAnak            string
+	Posyandu            string
+	ImunisasiTerlambat  []string
+	ImunisasiBerikutnya string
+	Tanggal             string // e.g. "Kamis, 10 Oktober 2024", UNDANGAN_TANGGAL_KOSONG when unknown
+	Tempat              string
+}
+
+// Compile fills the defaults of the invitation slips and parses their date. It is safe to call more than once.
+func (cfg *UndanganConfig) Compile() error {
+	if cfg.Judul == EMPTY_STRING {
+		cfg.Judul = UNDANGAN_JUDUL
+	}
+	if cfg.SlipPerHalaman == 0 {
+		cfg.SlipPerHalaman = UNDANGAN_SLIP_PER_PAGE
+	}
+	if _, exists := undanganSlipLayouts[cfg.SlipPerHalaman]; !exists {
+		return fmt.Errorf("invalid undangan slip_per_halaman %d, accepted values: 2, 4, 6, 8", cfg.SlipPerHalaman)
+	}
+
+	cfg.tanggal = time.Time{}
+	if cfg.Tanggal != EMPTY_STRING {
+		tanggal, err := time.Parse("2006-01-02", cfg.Tanggal)
+		if err != nil {
+			return fmt.Errorf("invalid undangan tanggal %q, expected format YYYY-MM-DD", cfg.Tanggal)
+		}
+		cfg.tanggal = tanggal
+	}
+	return nil
+}
+
+// GetUndanganTitle returns the title of the invitation slips for the given sasaran type and reference date
+func GetUndanganTitle(sasaranType string, tanggalAcuan time.Time) string {
+	return fmt.Sprintf("%s %s %s", UNDANGAN_JUDUL, CapitalizeFirstChar(sasaranType), GetDateStr(tanggalAcuan))
+}
+
+// GetUndangan returns the invitation slip of every child of the generated lists, in list order. The posyandu day
+// is the configured one, or else the next posyandu day of the child's posyandu configured for the reminders.
+func (fileGeneration *FileGeneration) GetUndangan() []Undangan {
+	undanganList := []Undangan{}
+	for _, generation := range fileGeneration.Generations {
+		cfg := generation.Cfg
+		for _, sasaranImunisasi := range generation.SasaranImunisasiList {
+			undangan := Undangan{
+				NamaAnak:            sasaranImunisasi.NamaAnak,
+				NamaOrangTua:        sasaranImunisasi.NamaOrangTua,
+				UsiaAnak:            sasaranImunisasi.UsiaAnak,
+				Posyandu:            sasaranImunisasi.Posyandu,
+				ImunisasiTerlambat:  sasaranImunisasi.ImunisasiTerlambat,
+				ImunisasiBerikutnya: sasaranImunisasi.ImunisasiBerikutnya,
+				Tanggal:             UNDANGAN_TANGGAL_KOSONG,
+				Tempat:              cfg.Undangan.Tempat,
+			}
+
+			tanggal := cfg.Undangan.tanggal
+			if tanggal.IsZero() {
+				tanggal = cfg.Pengingat.GetTanggalPosyandu(sasaranImunisasi.Posyandu, generation.TanggalAcuan)
+			}
+			if !tanggal.IsZero() {
+				undangan.Tanggal = GetTanggalLengkapStr(tanggal)
+			}
+			if undangan.Tempat == EMPTY_STRING {
+				undangan.Tempat = sasaranImunisasi.Posyandu
+			}
+			undanganList = append(undanganList, undangan)
+		}
+	}
+	return undanganList
+}
+
+// GenerateUndangan processes the provided source file like GenerateFile and returns the invitation slips as a PDF.
+func (svc *SasaranImunisasiService) GenerateUndangan(sourceFile XlsxSourceFile) (*PdfGeneratedFile, error) {
+	fileGeneration, err := svc.ReadSourceFile(sourceFile)
+	if err != nil {
+		return nil, err
+	}
+	return fileGeneration.NewUndanganPdf(), nil
+}
+
+// NewUndanganPdf returns the invitation slips of every child laid out several per A4 page, each framed by a
+// dashed line to be cut along.
+func (fileGeneration *FileGeneration) NewUndanganPdf() *PdfGeneratedFile {
+	cfg := &fileGeneration.Generations[0].Cfg.Undangan
+	title := GetUndanganTitle(fileGeneration.SasaranType, fileGeneration.TanggalAcuan)
+	writer := NewPdfWriter("P", title)
+	pdf := writer.Pdf
+	pdf.SetAutoPageBreak(false, 0)
+
+	undanganList := fileGeneration.GetUndangan()
+	if len(undanganList) == 0 {
+		pdf.AddPage()
+		pdf.CellFormat(0, PDF_LINE_HEIGHT, writer.Text("Tidak ada anak dengan imunisasi belum lengkap."), EMPTY_STRING, 1, "L", false, 0, EMPTY_STRING)
+	}
+
+	layout := undanganSlipLayouts[cfg.SlipPerHalaman]
+	if layout[0] == 0 {
+		layout = undanganSlipLayouts[UNDANGAN_SLIP_PER_PAGE]
+	}
+	columns, rows := layout[0], layout[1]
+	pageWidth, pageHeight := pdf.GetPageSize()
+	slipWidth := (pageWidth - 2*PDF_MARGIN) / float64(columns)
+	slipHeight := (pageHeight - 2*PDF_MARGIN) / float64(rows)
+	for i, undangan := range undanganList {
+		slot := i % (columns * rows)
+		if slot == 0 {
+			pdf.AddPage()
+		}
+		x := PDF_MARGIN + float64(slot%columns)*slipWidth
+		y := PDF_MARGIN + float64(slot/columns)*slipHeight
+		writer.WriteUndanganSlip(undangan, cfg, x, y, slipWidth, slipHeight)
+	}
+
+	return &PdfGeneratedFile{FileName: title + ".pdf", Pdf: pdf, Summary: fileGeneration.Summary}
+}
+
+// WriteUndanganSlip writes a single invitation slip in the given box. Lines not fitting above the signature are
+// left out, so a long list of antigens never overlaps the next slip.
+func (writer *PdfWriter) WriteUndanganSlip(undangan Undangan, cfg *UndanganConfig, x, y, width, height float64) {
+	const padding, signatureHeight = 5.0, 14.0
+	pdf := writer.Pdf
+	pdf.SetDashPattern([]float64{2, 1.5}, 0)
+	pdf.Rect(x, y, width, height, "D")
+	pdf.SetDashPattern([]float64{}, 0)
+
+	innerWidth := width - 2*padding
+	bottom := y + height - padding - signatureHeight
+	writeLines := func(text, style string) {
+		pdf.SetFont(PDF_FONT, style, 10)
+		for _, line := range pdf.SplitText(writer.Text(text), innerWidth) {
+			if pdf.GetY()+PDF_LINE_HEIGHT > bottom {
+				return
+			}
+			pdf.CellFormat(innerWidth, PDF_LINE_HEIGHT, line, EMPTY_STRING, 2, "L", false, 0, EMPTY_STRING)
+		}
+	}
+
+	pdf.SetXY(x+padding, y+padding)
+	pdf.SetFont(PDF_FONT, "B", 12)
+	pdf.CellFormat(innerWidth, 8, writer.Text(cfg.Judul), EMPTY_STRING, 2, "C", false, 0, EMPTY_STRING)
+	writeLines("Kepada Yth. "+undangan.NamaOrangTua, "B")
+	writeLines(fmt.Sprintf("Orang tua/wali dari %s (usia %s)", undangan.NamaAnak, undangan.UsiaAnak), EMPTY_STRING)
+	pdf.SetY(pdf.GetY() + 2)
+	pdf.SetX(x + padding)
+	writeLines("Dimohon membawa anak untuk imunisasi:", EMPTY_STRING)
+	if len(undangan.ImunisasiTerlambat) > 0 {
+		writeLines("Terlambat: "+strings.Join(undangan.ImunisasiTerlambat, ", "), "B")
+	}
+	if undangan.ImunisasiBerikutnya != HYPHEN {
+		writeLines("Berikutnya: "+undangan.ImunisasiBerikutnya, EMPTY_STRING)
+	}
+	pdf.SetY(pdf.GetY() + 2)
+	pdf.SetX(x + padding)
+	writeLines("Hari/tanggal: "+undangan.Tanggal, EMPTY_STRING)
+	if cfg.Jam != EMPTY_STRING {
+		writeLines("Jam: "+cfg.Jam, EMPTY_STRING)
+	}
+	writeLines("Tempat: "+undangan.Tempat, EMPTY_STRING)
+	if cfg.Pesan != EMPTY_STRING {
+		writeLines(cfg.Pesan, "I")
+	}
+
+	pdf.SetFont(PDF_FONT, EMPTY_STRING, 10)
+	pdf.SetXY(x+padding, bottom+2)
+	pdf.CellFormat(innerWidth, PDF_LINE_HEIGHT, writer.Text("Hormat kami,"), EMPTY_STRING, 2, "R", false, 0, EMPTY_STRING)
+	pdf.CellFormat(innerWidth, PDF_LINE_HEIGHT, writer.Text(cfg.Penandatangan), EMPTY_STRING, 2, "R", false, 0, EMPTY_STRING)
+}
@@ -0,0 +1,84 @@
+package sasaranimunisasi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestGenerateUndangan(t *testing.T) {
+	cfg := loadTestConfig(t)
+	cfg.Pengingat.HariPerPosyandu = map[string]int{"Posyandu Melati": 10}
+	cfg.PosyanduColumn = "Posyandu"
+	cfg.Undangan = UndanganConfig{Jam: "08.00 WIB", SlipPerHalaman: 2}
+	svc := newTestService(t, cfg)
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 3, 9, 0, 0, 0, time.UTC) }
+
+	file := excelize.NewFile()
+	defer file.Close()
+	rows := [][]interface{}{
+		{NAMA_ANAK, TANGGAL_LAHIR_ANAK, NAMA_ORANG_TUA, "Posyandu", "Status Imunisasi HB0"},
+		{"Ahmad", "2024-09-20", "Ibu Ahmad", "Posyandu Melati", "belum"},
+		{"Siti", "2024-09-01", "Ibu Siti", "Posyandu Mawar", "belum"},
+		{"Budi", "2024-09-10", "Ibu Budi", "Posyandu Mawar", "belum"},
+	}
+	for i, values := range rows {
+		file.SetSheetRow(SHEET_NAME, fmt.Sprintf("A%d", i+1), &values)
+	}
+	sourceFile := XlsxSourceFile{
+		Ctx:       context.WithValue(context.Background(), sasaranTypeKey, BAYI),
+		SheetName: SHEET_NAME,
+		Reader:    NewXlsxReader(file),
+	}
+
+	fileGeneration, err := svc.ReadSourceFile(sourceFile)
+	if err != nil {
+		t.Fatalf("reading source file: %v", err)
+	}
+	want := map[string][2]string{
+		"Ahmad": {"Kamis, 10 Oktober 2024", "Posyandu Melati"},
+		"Siti":  {UNDANGAN_TANGGAL_KOSONG, "Posyandu Mawar"},
+		"Budi":  {UNDANGAN_TANGGAL_KOSONG, "Posyandu Mawar"},
+	}
+	undanganList := fileGeneration.GetUndangan()
+	if len(undanganList) != len(want) {
+		t.Fatalf("got %d undangan, want %d", len(undanganList), len(want))
+	}
+	for _, undangan := range undanganList {
+		if got := [2]string{undangan.Tanggal, undangan.Tempat}; got != want[undangan.NamaAnak] {
+			t.Errorf("%s: got tanggal and tempat %q, want %q", undangan.NamaAnak, got, want[undangan.NamaAnak])
+		}
+	}
+
+	pdfFile := fileGeneration.NewUndanganPdf()
+	if pdfFile.FileName != "Undangan Imunisasi Bayi 3 Oktober.pdf" {
+		t.Errorf("got file name %q", pdfFile.FileName)
+	}
+	var buf bytes.Buffer
+	if err := pdfFile.Pdf.Output(&buf); err != nil {
+		t.Fatalf("writing PDF: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF")) {
+		t.Errorf("got output starting with %q, want a PDF", buf.Bytes()[:min(8, buf.Len())])
+	}
+	// three slips at two per page
+	if pages := pdfFile.Pdf.PageCount(); pages != 2 {
+		t.Errorf("got %d pages, want 2", pages)
+	}
+}
+
+func TestUndanganConfigCompile(t *testing.T) {
+	cfg := UndanganConfig{}
+	if err := cfg.Compile(); err != nil || cfg.Judul != UNDANGAN_JUDUL || cfg.SlipPerHalaman != UNDANGAN_SLIP_PER_PAGE {
+		t.Errorf("got %+v and error %v, want the defaults", cfg, err)
+	}
+	for _, invalid := range []UndanganConfig{{SlipPerHalaman: 3}, {Tanggal: "10-10-2024"}} {
+		if err := invalid.Compile(); err == nil {
+			t.Errorf("got no error for %+v", invalid)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package sasaranimunisasi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// consts for the watch-folder mode
+const (
+	WATCH_STATUS_OK    = "ok"
+	WATCH_STATUS_ERROR = "error"
+	ERROR_FILE_SUFFIX  = ".error.txt" // suffix of the file describing why an input moved to the error folder failed
+)
+
+// WatchConfig holds the folders and timing of the watch-folder mode, which generates the files of every
+// export dropped into the inbox folder.
+type WatchConfig struct {
+	InboxDir      string        `yaml:"inbox_dir"`
+	OutboxDir     string        `yaml:"outbox_dir"`     // generated files, named like the generated file title
+	ArchiveDir    string        `yaml:"archive_dir"`    // inputs generated successfully
+	ErrorDir      string        `yaml:"error_dir"`      // inputs that failed, each with a .error.txt file
+	StateFile     string        `yaml:"state_file"`     // JSON file of the processed inputs, so a restart does not reprocess them
+	PollInterval  time.Duration `yaml:"poll_interval"`  // e.g. 30s
+	SettleTime    time.Duration `yaml:"settle_time"`    // minimum age of an input, so files still being copied are not read
+	SheetName     string        `yaml:"sheet_name"`     // detected from the headers when empty or not found
+	PisahPosyandu string        `yaml:"pisah_posyandu"` // "sheet" or "zip" to split the list per posyandu
+}
+
+// Validate fills the defaults of the watch config and validates its per-posyandu split mode. It is safe to call
+// more than once.
+func (cfg *WatchConfig) Validate() error {
+	defaults := []struct {
+		value        *string
+		defaultValue string
+	}{
+		{&cfg.InboxDir, filepath.Join("watch", "inbox")},
+		{&cfg.OutboxDir, filepath.Join("watch", "outbox")},
+		{&cfg.ArchiveDir, filepath.Join("watch", "archive")},
+		{&cfg.ErrorDir, filepath.Join("watch", "error")},
+		{&cfg.StateFile, filepath.Join("watch", "state.json")},
+	}
+	for _, d := range defaults {
+		if *d.value == EMPTY_STRING {
+			*d.value = d.defaultValue
+		}
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 30 * time.Second
+	}
+	if cfg.SettleTime < 0 {
+		return fmt.Errorf("invalid watch settle_time %s", cfg.SettleTime)
+	}
+
+	pisahPosyandu, err := ValidatePisahPosyandu(cfg.PisahPosyandu)
+	if err != nil {
+		return fmt.Errorf("invalid watch config: %w", err)
+	}
+	cfg.PisahPosyandu = pisahPosyandu
+	return nil
+}
+
+// WatchState holds the inputs processed by the watch-folder mode, saved to the state file after every input.
+type WatchState struct {
+	Processed map[string]WatchedFile `json:"processed"` // by input key, see GetWatchKey
+}
+
+// WatchedFile represents an input processed by the watch-folder mode.
+type WatchedFile struct {
+	Name        string    `json:"name"`
+	SasaranType string    `json:"sasaranType"`
+	ProcessedAt time.Time `json:"processedAt"`
+	Status      string    `json:"status"`
+	OutputPath  string    `json:"outputPath,omitempty"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Watcher generates the files of the exports dropped into the inbox folder. It is not safe for concurrent use.
+type Watcher struct {
+	Svc   *SasaranImunisasiService
+	Cfg   WatchConfig
+	State WatchState
+	Clock func() time.Time
+}
+
+// NewWatcher validates the watch config, creates its folders and loads the state file when it exists.
+func (svc *SasaranImunisasiService) NewWatcher(cfg WatchConfig) (*Watcher, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	for _, dir := range []string{cfg.InboxDir, cfg.OutboxDir, cfg.ArchiveDir, cfg.ErrorDir, filepath.Dir(cfg.StateFile)} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	watcher := &Watcher{Svc: svc, Cfg: cfg, State: WatchState{Processed: make(map[string]WatchedFile)}, Clock: time.Now}
+	content, err := os.ReadFile(cfg.StateFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return watcher, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &watcher.State); err != nil {
+		return nil, fmt.Errorf("invalid watch state file %s: %w", cfg.StateFile, err)
+	}
+	if watcher.State.Processed == nil {
+		watcher.State.Processed = make(map[string]WatchedFile)
+	}
+	return watcher, nil
+}
+
+// Run polls the inbox folder every poll interval until the context is done.
+func (watcher *Watcher) Run(ctx context.Context) error {
+	log.Printf("Watching %s every %s, writing to %s", watcher.Cfg.InboxDir, watcher.Cfg.PollInterval, watcher.Cfg.OutboxDir)
+	ticker := time.NewTicker(watcher.Cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := watcher.Poll(); err != nil {
+			log.Printf("Error polling %s: %v", watcher.Cfg.InboxDir, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll generates the files of the inputs of the inbox folder older than the settle time and returns the result
+// of each, in name order. Every input is moved to the archive folder, or to the error folder when it fails, and
+// recorded in the state file. An input already recorded, whose move failed before, is only moved again.
+func (watcher *Watcher) Poll() ([]BatchResult, error) {
+	inputPaths, err := ListSourceFiles(watcher.Cfg.InboxDir)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []BatchResult{}
+	for _, inputPath := range inputPaths {
+		info, err := os.Stat(inputPath)
+		if err != nil {
+			log.Printf("Error reading %s: %v", inputPath, err)
+			continue
+		}
+		if watcher.Clock().Sub(info.ModTime()) < watcher.Cfg.SettleTime {
+			continue
+		}
+
+		key := GetWatchKey(info)
+		if watched, exists := watcher.State.Processed[key]; exists {
+			log.Printf("Skipping %s, already processed at %s", inputPath, watched.ProcessedAt.Format(time.RFC3339))
+			watcher.MoveInput(inputPath, watched)
+			continue
+		}
+
+		result, watched := watcher.Process(inputPath)
+		watcher.State.Processed[key] = watched
+		if err := watcher.SaveState(); err != nil {
+			return results, err
+		}
+		watcher.MoveInput(inputPath, watched)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Process generates the files of a single input into the outbox folder, with the sasaran type inferred from
+// its file name.
+func (watcher *Watcher) Process(inputPath string) (BatchResult, WatchedFile) {
+	watched := WatchedFile{
+		Name:        filepath.Base(inputPath),
+		SasaranType: InferSasaranType(filepath.Base(inputPath)),
+		ProcessedAt: watcher.Clock(),
+	}
+	result := BatchResult{InputPath: inputPath}
+
+	opts := BatchOptions{SheetName: watcher.Cfg.SheetName, SasaranType: watched.SasaranType, PisahPosyandu: watcher.Cfg.PisahPosyandu}
+	ctx, err := opts.NewContext(context.Background())
+	var generatedFile *XlsxGeneratedFile
+	if err == nil {
+		generatedFile, err = watcher.Svc.GenerateFileFromPath(ctx, inputPath, opts.SheetName)
+	}
+	if err == nil {
+		result.Summary = generatedFile.Summary
+		result.OutputPath, err = WriteGeneratedFile(watcher.Cfg.OutboxDir, generatedFile, GetUsedFileNames(watcher.Cfg.OutboxDir))
+	}
+
+	if err != nil {
+		log.Printf("Error generating %s: %v", inputPath, err)
+		result.Err = err
+		watched.Status, watched.Error = WATCH_STATUS_ERROR, err.Error()
+		return result, watched
+	}
+	log.Printf("Generated %s from %s", result.OutputPath, inputPath)
+	watched.Status, watched.OutputPath = WATCH_STATUS_OK, result.OutputPath
+	return result, watched
+}
+
+// MoveInput moves a processed input to the archive folder, or to the error folder next to a file describing
+// the error. The input keeps its name unless the folder already holds a file of that name.
+func (watcher *Watcher) MoveInput(inputPath string, watched WatchedFile) {
+	dir := watcher.Cfg.ArchiveDir
+	if watched.Status == WATCH_STATUS_ERROR {
+		dir = watcher.Cfg.ErrorDir
+	}
+
+	movedPath := filepath.Join(dir, GetUniqueFileName(filepath.Base(inputPath), GetUsedFileNames(dir)))
+	if err := os.Rename(inputPath, movedPath); err != nil {
+		log.Printf("Error moving %s to %s: %v", inputPath, dir, err)
+		return
+	}
+	if watched.Status == WATCH_STATUS_ERROR {
+		if err := os.WriteFile(movedPath+ERROR_FILE_SUFFIX, []byte(watched.Error+"\n"), 0o644); err != nil {
+			log.Printf("Error writing the error file of %s: %v", movedPath, err)
+		}
+	}
+}
+
+// SaveState writes the state to the state file, replacing it at once so a crash never leaves it half written.
+func (watcher *Watcher) SaveState() error {
+	content, err := json.MarshalIndent(watcher.State, EMPTY_STRING, "  ")
+	if err != nil {
+		return err
+	}
+	tempPath := watcher.Cfg.StateFile + ".tmp"
+	if err := os.WriteFile(tempPath, content, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, watcher.Cfg.StateFile)
+}
+
+// GetWatchKey returns the key identifying an input in the state, built from its name, size and modification
+// time, so an export dropped again under the same name but with another content is processed again.
+func GetWatchKey(info os.FileInfo) string {
+	return fmt.Sprintf("%s|%d|%d", info.Name(), info.Size(), info.ModTime().UnixNano())
+}
+
+// InferSasaranType returns the sasaran type named in the file name, e.g. "export baduta oktober.xlsx", or an
+// empty sasaran type when it names none, so every child is classified by age from the content.
+func InferSasaranType(fileName string) string {
+	words := strings.FieldsFunc(strings.ToLower(fileName), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	for _, word := range words {
+		for _, sasaranType := range AcceptedSasaranTypes {
+			if word == sasaranType {
+				return sasaranType
+			}
+		}
+	}
+	return EMPTY_STRING
+}
+
+// GetUsedFileNames returns the lower-case names of the files of the given folder, to be passed to GetUniqueFileName.
+func GetUsedFileNames(dir string) map[string]bool {
+	usedNames := make(map[string]bool)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("Error reading %s: %v", dir, err)
+		return usedNames
+	}
+	for _, entry := range entries {
+		usedNames[strings.ToLower(entry.Name())] = true
+	}
+	return usedNames
+}
@@ -0,0 +1,102 @@
+package sasaranimunisasi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInferSasaranType(t *testing.T) {
+	tests := map[string]string{
+		"export bayi oktober.xlsx":   BAYI,
+		"Sasaran_BADUTA-2024.xlsx":   BADUTA,
+		"semua.csv":                  SEMUA,
+		"export wanasari.xlsx":       EMPTY_STRING,
+		"bayibaduta tanpa spasi.csv": EMPTY_STRING,
+	}
+	for fileName, want := range tests {
+		if got := InferSasaranType(fileName); got != want {
+			t.Errorf("InferSasaranType(%q) = %q, want %q", fileName, got, want)
+		}
+	}
+}
+
+func TestWatcherPoll(t *testing.T) {
+	svc := newTestService(t, loadTestConfig(t))
+	svc.Clock = func() time.Time { return time.Date(2024, time.October, 1, 9, 0, 0, 0, time.UTC) }
+	dir := t.TempDir()
+	cfg := WatchConfig{
+		InboxDir:   filepath.Join(dir, "inbox"),
+		OutboxDir:  filepath.Join(dir, "outbox"),
+		ArchiveDir: filepath.Join(dir, "archive"),
+		ErrorDir:   filepath.Join(dir, "error"),
+		StateFile:  filepath.Join(dir, "state.json"),
+		SettleTime: time.Minute,
+	}
+	watcher, err := svc.NewWatcher(cfg)
+	if err != nil {
+		t.Fatalf("creating watcher: %v", err)
+	}
+
+	// only the inputs older than the settle time are read
+	source := newSourceXlsx(t, svc.SasaranBayiColumnMap, "Bayi", 5)
+	inputs := map[string][]byte{
+		"export bayi.xlsx": source,
+		"salah.csv":        []byte("Nama\tAlamat\nBudi\tWanasari\n"),
+		"baru.xlsx":        source,
+	}
+	for name, content := range inputs {
+		path := filepath.Join(cfg.InboxDir, name)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+		if name != "baru.xlsx" {
+			old := time.Now().Add(-time.Hour)
+			os.Chtimes(path, old, old)
+		}
+	}
+
+	results, err := watcher.Poll()
+	if err != nil {
+		t.Fatalf("polling: %v", err)
+	}
+	if len(results) != 2 || results[0].Err != nil || results[1].Err == nil {
+		t.Fatalf("got results %+v, want export bayi.xlsx generated and salah.csv failed", results)
+	}
+	if filepath.Base(results[0].OutputPath) != "Sasaran Imunisasi Bayi 1 Oktober.xlsx" {
+		t.Errorf("got output %q, want the generated file name", results[0].OutputPath)
+	}
+	for _, path := range []string{
+		filepath.Join(cfg.ArchiveDir, "export bayi.xlsx"),
+		filepath.Join(cfg.ErrorDir, "salah.csv"),
+		filepath.Join(cfg.ErrorDir, "salah.csv"+ERROR_FILE_SUFFIX),
+		filepath.Join(cfg.InboxDir, "baru.xlsx"),
+		cfg.StateFile,
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("want %s: %v", path, err)
+		}
+	}
+
+	// a restarted watcher does not reprocess an input recorded in the state file
+	archived := filepath.Join(cfg.ArchiveDir, "export bayi.xlsx")
+	info, _ := os.Stat(archived)
+	if err := os.Rename(archived, filepath.Join(cfg.InboxDir, "export bayi.xlsx")); err != nil {
+		t.Fatalf("moving back: %v", err)
+	}
+	os.Remove(filepath.Join(cfg.InboxDir, "baru.xlsx"))
+	restarted, err := svc.NewWatcher(cfg)
+	if err != nil {
+		t.Fatalf("restarting watcher: %v", err)
+	}
+	if _, exists := restarted.State.Processed[GetWatchKey(info)]; !exists {
+		t.Fatalf("got state %v, want export bayi.xlsx", restarted.State.Processed)
+	}
+	if results, err := restarted.Poll(); err != nil || len(results) != 0 {
+		t.Errorf("got results %+v and error %v, want nothing processed again", results, err)
+	}
+	if outputs, _ := os.ReadDir(cfg.OutboxDir); len(outputs) != 1 {
+		t.Errorf("got %d outputs, want 1", len(outputs))
+	}
+}
@@ -0,0 +1,178 @@
+package sasaranimunisasi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// WilayahConfig holds the service area (wilayah) rule set used to decide which source rows belong to the puskesmas.
+// Exclude rules are checked first, then include rules, and DefaultAction applies to rows whose checked values
+// match no rule. Rows without any checked value are always included.
+type WilayahConfig struct {
+	DefaultAction string        `yaml:"default_action"` // "include" or "exclude"
+	Include       []WilayahRule `yaml:"include"`
+	Exclude       []WilayahRule `yaml:"exclude"`
+}
+
+// WilayahRule matches the values of a source column against a list of values. Column matches the source
+// header with the same name or any header starting with the name followed by a space, so "Pos" checks
+// every "Pos Imunisasi ..." column.
+type WilayahRule struct {
+	Name            string   `yaml:"name"`
+	Column          string   `yaml:"column"`
+	Match           string   `yaml:"match"` // "contains", "exact" or "regex"
+	CaseInsensitive bool     `yaml:"case_insensitive"`
+	Values          []string `yaml:"values"`
+
+	patterns []*regexp.Regexp
+}
+
+// consts for wilayah rules
+const (
+	WILAYAH_INCLUDE        = "include"
+	WILAYAH_EXCLUDE        = "exclude"
+	WILAYAH_MATCH_CONTAINS = "contains"
+	WILAYAH_MATCH_EXACT    = "exact"
+	WILAYAH_MATCH_REGEX    = "regex"
+	WILAYAH_DEFAULT_RULE   = "default"
+)
+
+// Compile validates the rule set and compiles regex rules. It is safe to call more than once.
+func (wilayah *WilayahConfig) Compile() error {
+	switch wilayah.DefaultAction {
+	case EMPTY_STRING:
+		wilayah.DefaultAction = WILAYAH_INCLUDE
+	case WILAYAH_INCLUDE, WILAYAH_EXCLUDE:
+	default:
+		return fmt.Errorf("invalid wilayah default_action %q", wilayah.DefaultAction)
+	}
+
+	for _, rules := range [][]WilayahRule{wilayah.Include, wilayah.Exclude} {
+		for i := range rules {
+			if err := rules[i].compile(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compile validates the match mode of the rule and compiles its regex patterns.
+func (rule *WilayahRule) compile() error {
+	switch rule.Match {
+	case EMPTY_STRING:
+		rule.Match = WILAYAH_MATCH_CONTAINS
+	case WILAYAH_MATCH_CONTAINS, WILAYAH_MATCH_EXACT:
+	case WILAYAH_MATCH_REGEX:
+		rule.patterns = rule.patterns[:0]
+		for _, value := range rule.Values {
+			if rule.CaseInsensitive {
+				value = "(?i)" + value
+			}
+			pattern, err := regexp.Compile(value)
+			if err != nil {
+				return fmt.Errorf("invalid regex in wilayah rule %q: %w", rule.GetName(), err)
+			}
+			rule.patterns = append(rule.patterns, pattern)
+		}
+	default:
+		return fmt.Errorf("invalid match %q in wilayah rule %q", rule.Match, rule.GetName())
+	}
+	return nil
+}
+
+// GetName returns the configured rule name, or a description of the rule when no name is configured.
+func (rule *WilayahRule) GetName() string {
+	if rule.Name != EMPTY_STRING {
+		return rule.Name
+	}
+	return fmt.Sprintf("%s %s %s", rule.Column, rule.Match, strings.Join(rule.Values, "|"))
+}
+
+// IsColumnChecked reports whether the rule checks the given source column.
+func (rule *WilayahRule) IsColumnChecked(sourceColumnName string) bool {
+	return sourceColumnName == rule.Column || strings.HasPrefix(sourceColumnName, rule.Column+SPACE)
+}
+
+// IsMatch reports whether the cell value matches one of the rule values.
+func (rule *WilayahRule) IsMatch(cellValue string) bool {
+	if rule.Match == WILAYAH_MATCH_REGEX {
+		for _, pattern := range rule.patterns {
+			if pattern.MatchString(cellValue) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, value := range rule.Values {
+		cell := cellValue
+		if rule.CaseInsensitive {
+			cell, value = strings.ToLower(cell), strings.ToLower(value)
+		}
+		if rule.Match == WILAYAH_MATCH_EXACT && cell == value {
+			return true
+		}
+		if rule.Match == WILAYAH_MATCH_CONTAINS && strings.Contains(cell, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsColumnChecked reports whether any rule of the rule set checks the given source column.
+func (wilayah *WilayahConfig) IsColumnChecked(sourceColumnName string) bool {
+	for _, rules := range [][]WilayahRule{wilayah.Exclude, wilayah.Include} {
+		for i := range rules {
+			if rules[i].IsColumnChecked(sourceColumnName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckRow evaluates the rule set against a source row. getCellValue returns the value of a source column
+// for the row. It returns whether the row is included and, for excluded rows, the name of the rule that
+// excluded it. The checked columns are evaluated in header order, so the first matching column names the rule.
+func (wilayah *WilayahConfig) CheckRow(sourceColumnMap map[string]Column, getCellValue func(column Column) string) (bool, string) {
+	checkedColumns := []string{}
+	for sourceColumnName := range sourceColumnMap {
+		if wilayah.IsColumnChecked(sourceColumnName) {
+			checkedColumns = append(checkedColumns, sourceColumnName)
+		}
+	}
+	sort.Slice(checkedColumns, func(i, j int) bool {
+		return sourceColumnMap[checkedColumns[i]].Index < sourceColumnMap[checkedColumns[j]].Index
+	})
+
+	hasValue, hasIncludeMatch := false, false
+	for _, sourceColumnName := range checkedColumns {
+		cellValue := getCellValue(sourceColumnMap[sourceColumnName])
+		if cellValue == HYPHEN {
+			continue
+		}
+		hasValue = true
+
+		for i := range wilayah.Exclude {
+			rule := &wilayah.Exclude[i]
+			if rule.IsColumnChecked(sourceColumnName) && rule.IsMatch(cellValue) {
+				return false, rule.GetName()
+			}
+		}
+
+		for i := range wilayah.Include {
+			rule := &wilayah.Include[i]
+			if rule.IsColumnChecked(sourceColumnName) && rule.IsMatch(cellValue) {
+				hasIncludeMatch = true
+			}
+		}
+	}
+
+	if hasIncludeMatch || !hasValue || wilayah.DefaultAction != WILAYAH_EXCLUDE {
+		return true, EMPTY_STRING
+	}
+	return false, WILAYAH_DEFAULT_RULE
+}
@@ -0,0 +1,92 @@
+package sasaranimunisasi
+
+import "testing"
+
+func TestWilayahRuleIsMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      WilayahRule
+		cellValue string
+		want      bool
+	}{
+		{"contains", WilayahRule{Match: WILAYAH_MATCH_CONTAINS, Values: []string{"Wanasari"}}, "Posyandu Wanasari 1", true},
+		{"contains case sensitive", WilayahRule{Match: WILAYAH_MATCH_CONTAINS, Values: []string{"wanasari"}}, "Posyandu Wanasari 1", false},
+		{"contains case insensitive", WilayahRule{Match: WILAYAH_MATCH_CONTAINS, CaseInsensitive: true, Values: []string{"wanasari"}}, "Posyandu Wanasari 1", true},
+		{"contains by default", WilayahRule{Values: []string{"Wanasari"}}, "Posyandu Wanasari 1", true},
+		{"exact", WilayahRule{Match: WILAYAH_MATCH_EXACT, Values: []string{"Wanasari"}}, "Wanasari", true},
+		{"exact on a longer value", WilayahRule{Match: WILAYAH_MATCH_EXACT, Values: []string{"Wanasari"}}, "Posyandu Wanasari", false},
+		{"exact case insensitive", WilayahRule{Match: WILAYAH_MATCH_EXACT, CaseInsensitive: true, Values: []string{"WANASARI"}}, "wanasari", true},
+		{"regex", WilayahRule{Match: WILAYAH_MATCH_REGEX, Values: []string{`^RT 0[1-5]\b`}}, "RT 03 RW 02", true},
+		{"regex no match", WilayahRule{Match: WILAYAH_MATCH_REGEX, Values: []string{`^RT 0[1-5]\b`}}, "RT 07 RW 02", false},
+		{"regex case insensitive", WilayahRule{Match: WILAYAH_MATCH_REGEX, CaseInsensitive: true, Values: []string{`^rt 0[1-5]\b`}}, "RT 03 RW 02", true},
+		{"any value", WilayahRule{Match: WILAYAH_MATCH_EXACT, Values: []string{"Cibuntu", "Wanasari"}}, "Wanasari", true},
+	}
+	for _, test := range tests {
+		wilayah := WilayahConfig{Include: []WilayahRule{test.rule}}
+		if err := wilayah.Compile(); err != nil {
+			t.Fatalf("%s: compiling rule: %v", test.name, err)
+		}
+		if got := wilayah.Include[0].IsMatch(test.cellValue); got != test.want {
+			t.Errorf("%s: IsMatch(%q) = %v, want %v", test.name, test.cellValue, got, test.want)
+		}
+	}
+}
+
+func TestWilayahCompile(t *testing.T) {
+	for name, wilayah := range map[string]WilayahConfig{
+		"default action": {DefaultAction: "drop"},
+		"match":          {Include: []WilayahRule{{Column: "Pos", Match: "prefix"}}},
+		"regex":          {Exclude: []WilayahRule{{Column: "Pos", Match: WILAYAH_MATCH_REGEX, Values: []string{"RT ("}}}},
+	} {
+		if err := wilayah.Compile(); err == nil {
+			t.Errorf("%s: got no error for an invalid rule set", name)
+		}
+	}
+}
+
+func TestWilayahCheckRow(t *testing.T) {
+	sourceColumnMap := map[string]Column{
+		NAMA_ANAK:              {Index: 0},
+		"Pos Imunisasi HB0":    {Index: 1},
+		"Pos Imunisasi BCG 1":  {Index: 2},
+		"Pos Imunisasi POLIO1": {Index: 3},
+	}
+	include := WilayahRule{Name: "pos wanasari", Column: "Pos", CaseInsensitive: true, Values: []string{"wanasari"}}
+	excludeCibuntu := WilayahRule{Name: "pos cibuntu", Column: "Pos", CaseInsensitive: true, Values: []string{"cibuntu"}}
+	excludeLuar := WilayahRule{Name: "pos luar", Column: "Pos", CaseInsensitive: true, Values: []string{"luar"}}
+
+	tests := []struct {
+		name          string
+		wilayah       WilayahConfig
+		values        []string // values of the columns by index
+		wantIncluded  bool
+		wantExcludeBy string
+	}{
+		{"include match", WilayahConfig{Include: []WilayahRule{include}, DefaultAction: WILAYAH_EXCLUDE},
+			[]string{"Ahmad", "Wanasari", HYPHEN, HYPHEN}, true, EMPTY_STRING},
+		{"exclude before include", WilayahConfig{Include: []WilayahRule{include}, Exclude: []WilayahRule{excludeCibuntu}},
+			[]string{"Ahmad", "Wanasari", "Cibuntu", HYPHEN}, false, "pos cibuntu"},
+		{"first excluding column in header order", WilayahConfig{Exclude: []WilayahRule{excludeCibuntu, excludeLuar}},
+			[]string{"Ahmad", "Luar Wilayah", "Cibuntu", "Luar Wilayah"}, false, "pos luar"},
+		{"default include", WilayahConfig{Include: []WilayahRule{include}},
+			[]string{"Ahmad", "Bojong", HYPHEN, HYPHEN}, true, EMPTY_STRING},
+		{"default exclude", WilayahConfig{Include: []WilayahRule{include}, DefaultAction: WILAYAH_EXCLUDE},
+			[]string{"Ahmad", "Bojong", HYPHEN, HYPHEN}, false, WILAYAH_DEFAULT_RULE},
+		{"no checked value", WilayahConfig{Include: []WilayahRule{include}, DefaultAction: WILAYAH_EXCLUDE},
+			[]string{"Ahmad", HYPHEN, HYPHEN, HYPHEN}, true, EMPTY_STRING},
+		{"unchecked column", WilayahConfig{Exclude: []WilayahRule{{Name: "nama", Column: "Pos", Values: []string{"Ahmad"}}}},
+			[]string{"Ahmad", "Wanasari", HYPHEN, HYPHEN}, true, EMPTY_STRING},
+	}
+	for _, test := range tests {
+		if err := test.wilayah.Compile(); err != nil {
+			t.Fatalf("%s: compiling rule set: %v", test.name, err)
+		}
+		// run several times, as the source column map is iterated in random order
+		for range 10 {
+			included, excludedBy := test.wilayah.CheckRow(sourceColumnMap, func(column Column) string { return test.values[column.Index] })
+			if included != test.wantIncluded || excludedBy != test.wantExcludeBy {
+				t.Fatalf("%s: got %v excluded by %q, want %v excluded by %q", test.name, included, excludedBy, test.wantIncluded, test.wantExcludeBy)
+			}
+		}
+	}
+}
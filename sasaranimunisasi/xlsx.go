@@ -3,44 +3,48 @@ package sasaranimunisasi
 import (
 	"context"
 	"log"
+	"strconv"
+	"time"
 
 	"github.com/xuri/excelize/v2"
 )
 
-// XlsxSourceFile contains information about the source Excel file,
-// including its temporary file path, the sheet name, and the opened Excel file.
+// XlsxSourceFile contains information about the source file,
+// including its temporary file path, the sheet name, and the reader of the opened xlsx or CSV file.
 type XlsxSourceFile struct {
 	Ctx          context.Context
 	TempFilePath string
 	SheetName    string
-	ExcelizeFile *excelize.File
+	Reader       SourceReader
 }
 
 // XlsxGeneratedFile holds the generated Excel file details,
-// including its filename and the Excelize file pointer.
+// including its filename, the Excelize file pointer and the row counts of the generation.
+// PosyanduFiles holds one more file per posyandu when the list is split into a ZIP.
 type XlsxGeneratedFile struct {
-	FileName     string
-	ExcelizeFile *excelize.File
+	FileName      string
+	ExcelizeFile  *excelize.File
+	RejectedRows  []RejectedRow
+	Summary       GenerationSummary
+	PosyanduFiles []*XlsxGeneratedFile
+	PengingatList []Pengingat // reminder message of every child, empty when reminders are not configured
 }
 
-// XlsxFileTransformer is an interface defining the method to generate a new Excel file
-// from a source Excel file.
+// XlsxFileTransformer is an interface defining the methods to select the sheet of a source Excel file
+// and to generate a new Excel file, the equivalent JSON document, the reminder messages, the invitation slips or a print-ready PDF file from it.
 type XlsxFileTransformer interface {
+	SelectSourceSheet(sourceFile *XlsxSourceFile) error
 	GenerateFile(sourceFile XlsxSourceFile) (*XlsxGeneratedFile, error)
-}
-
-// GetCellValue retrieves the value of a cell; returns "-" if an error occurs or the value is empty.
-func GetCellValue(sourceFile XlsxSourceFile, cell string) string {
-	cellValue, err := sourceFile.ExcelizeFile.GetCellValue(sourceFile.SheetName, cell)
-	if err != nil || cellValue == "" {
-		return "-"
-	}
-	return cellValue
+	GenerateDocument(sourceFile XlsxSourceFile) (*SasaranImunisasiDocument, error)
+	GeneratePengingat(sourceFile XlsxSourceFile) ([]Pengingat, error)
+	GenerateUndangan(sourceFile XlsxSourceFile) (*PdfGeneratedFile, error)
+	GenerateSasaranPdf(sourceFile XlsxSourceFile) (*PdfGeneratedFile, error)
 }
 
 // Column represents column characteristics of xlsx file
 type Column struct {
 	Label string
+	Index int // zero-based position of the column in a source row
 	Width float64
 }
 
@@ -60,20 +64,33 @@ func GetXlsxColumnLabel(index int) string {
 	return label
 }
 
-// NewXlsxFile represents the structure of a new Excel file.
+// GetColumnIndex returns the zero-based index of an Excel column label, e.g. 0 for "A"; -1 if the label is invalid.
+func GetColumnIndex(label string) int {
+	number, err := excelize.ColumnNameToNumber(label)
+	if err != nil {
+		return -1
+	}
+	return number - 1
+}
+
+// NewXlsxFile represents the structure of a new Excel file. Every sheet is written with a stream writer,
+// so generators set the column width first and then write their rows from top to bottom.
 type NewXlsxFile struct {
 	Ctx            context.Context
 	SheetName      string
 	ExcelizeFile   *excelize.File
+	StreamWriter   *excelize.StreamWriter
 	TitleRowAt     int
 	HeaderRowAt    int
 	StartBodyRowAt int
 	TitleStyle     int
 	HeaderStyle    int
 	BodyStyle      int
+	DateStyle      int // body style with the dd-mm-yyyy number format, used for every time.Time value
 }
 
-// NewXlsxGenerator defines methods for generating a new Excel file.
+// NewXlsxGenerator defines methods for generating a new Excel file. They are called in the order
+// SetColumnWidth, SetTitle, SetHeader and SetBody.
 type NewXlsxGenerator interface {
 	SetTitle(newFile NewXlsxFile)
 	SetHeader(newFile NewXlsxFile)
@@ -81,6 +98,40 @@ type NewXlsxGenerator interface {
 	SetColumnWidth(newFile NewXlsxFile)
 }
 
+// SetRow writes the values in the given row starting at column A, every cell with the given style except
+// dates which are written as date cells with the date style. Nil values are written as empty styled cells.
+// Row options, e.g. the row height, are passed to the stream writer.
+func (newFile NewXlsxFile) SetRow(rowAt int, values []interface{}, styleID int, opts ...excelize.RowOpts) {
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		cells[i] = excelize.Cell{StyleID: styleID, Value: value}
+		if _, isDate := value.(time.Time); isDate {
+			cells[i] = excelize.Cell{StyleID: newFile.DateStyle, Value: value}
+		}
+	}
+	if err := newFile.StreamWriter.SetRow(A+strconv.Itoa(rowAt), cells, opts...); err != nil {
+		log.Printf("Error writing row %d of sheet %s: %v", rowAt, newFile.SheetName, err)
+	}
+}
+
+// ToInterfaces returns the values as a slice of interface{}, e.g. to write a header with SetRow.
+func ToInterfaces(values []string) []interface{} {
+	interfaces := make([]interface{}, len(values))
+	for i, value := range values {
+		interfaces[i] = value
+	}
+	return interfaces
+}
+
+// SetTitleRow writes the title in column A of the title row, merged over the given number of columns.
+func (newFile NewXlsxFile) SetTitleRow(title string, columns int) {
+	rowAt := strconv.Itoa(newFile.TitleRowAt)
+	newFile.SetRow(newFile.TitleRowAt, []interface{}{title}, newFile.TitleStyle)
+	if err := newFile.StreamWriter.MergeCell(A+rowAt, GetXlsxColumnLabel(columns)+rowAt); err != nil {
+		log.Printf("Error merging title of sheet %s: %v", newFile.SheetName, err)
+	}
+}
+
 // consts for xlsx file
 const (
 	BLACK_COLOR = "#000000"
@@ -90,18 +141,46 @@ const (
 
 // CreateNewXlsxFile creates a new Excel file and sets up its styles and structure.
 func CreateNewXlsxFile(ctx context.Context, generator NewXlsxGenerator) (*excelize.File, error) {
+	return CreateNewXlsxWorkbook(ctx, []XlsxSheet{{Name: SHEET_NAME, Generator: generator}})
+}
+
+// XlsxSheet pairs a sheet name with the generator filling the sheet.
+type XlsxSheet struct {
+	Name      string
+	Generator NewXlsxGenerator
+}
+
+// CreateNewXlsxWorkbook creates a new Excel file containing the given sheets in order, the first sheet is active.
+func CreateNewXlsxWorkbook(ctx context.Context, sheets []XlsxSheet) (*excelize.File, error) {
 	excelizeFile := excelize.NewFile()
-	index, err := excelizeFile.NewSheet(SHEET_NAME)
-	if err != nil {
-		return nil, err
+	excelizeFile.SetDefaultFont(FONT_TYPE)
+
+	for i, sheet := range sheets {
+		if i == 0 && sheet.Name != SHEET_NAME {
+			if err := excelizeFile.SetSheetName(SHEET_NAME, sheet.Name); err != nil {
+				return nil, err
+			}
+		}
+		if err := AddXlsxSheet(ctx, excelizeFile, sheet.Name, sheet.Generator); err != nil {
+			return nil, err
+		}
 	}
+	excelizeFile.SetActiveSheet(0)
 
-	excelizeFile.SetActiveSheet(index)
-	excelizeFile.SetDefaultFont(FONT_TYPE)
+	return excelizeFile, nil
+}
+
+// AddXlsxSheet adds a sheet with the given name to the Excel file, or reuses it when it already exists,
+// and lets the generator stream its column width, title, header and body. The layout of a table sheet is
+// applied first.
+func AddXlsxSheet(ctx context.Context, excelizeFile *excelize.File, sheetName string, generator NewXlsxGenerator) error {
+	if _, err := excelizeFile.NewSheet(sheetName); err != nil {
+		return err
+	}
 
 	newXlsxFile := NewXlsxFile{
 		Ctx:            ctx,
-		SheetName:      SHEET_NAME,
+		SheetName:      sheetName,
 		ExcelizeFile:   excelizeFile,
 		TitleRowAt:     1,
 		HeaderRowAt:    3,
@@ -109,15 +188,26 @@ func CreateNewXlsxFile(ctx context.Context, generator NewXlsxGenerator) (*exceli
 	}
 
 	if err := setStylesForNewFile(excelizeFile, &newXlsxFile); err != nil {
-		return nil, err
+		return err
 	}
 
+	streamWriter, err := excelizeFile.NewStreamWriter(sheetName)
+	if err != nil {
+		return err
+	}
+	newXlsxFile.StreamWriter = streamWriter
+	if tableGenerator, isTable := generator.(XlsxTableGenerator); isTable {
+		if err := newXlsxFile.ApplyXlsxLayout(tableGenerator.GetXlsxLayout()); err != nil {
+			return err
+		}
+	}
+
+	generator.SetColumnWidth(newXlsxFile)
 	generator.SetTitle(newXlsxFile)
 	generator.SetHeader(newXlsxFile)
 	generator.SetBody(newXlsxFile)
-	generator.SetColumnWidth(newXlsxFile)
 
-	return excelizeFile, nil
+	return streamWriter.Flush()
 }
 
 // setStylesForNewFile creates and assigns styles for the title, header, and body.
@@ -141,13 +231,39 @@ func setStylesForNewFile(file *excelize.File, newFile *NewXlsxFile) error {
 
 	newFile.HeaderStyle = SetXlsxStyle(file, true)
 	newFile.BodyStyle = SetXlsxStyle(file, false)
+	newFile.DateStyle = SetXlsxDateStyle(file)
 
 	return nil
 }
 
 // SetXlsxStyle creates a style for Excel cells based on whether it is a header.
 func SetXlsxStyle(file *excelize.File, isHeader bool) int {
-	style, err := file.NewStyle(&excelize.Style{
+	style, err := file.NewStyle(getXlsxStyle(isHeader))
+	if err != nil {
+		log.Printf("Error creating style for header: %v", err)
+		return 0
+	}
+
+	return style
+}
+
+// SetXlsxDateStyle creates the style of the body cells holding a date, shown in the format dd-mm-yyyy.
+func SetXlsxDateStyle(file *excelize.File) int {
+	dateStyle := getXlsxStyle(false)
+	numFmt := DATE_NUMBER_FMT
+	dateStyle.CustomNumFmt = &numFmt
+	style, err := file.NewStyle(dateStyle)
+	if err != nil {
+		log.Printf("Error creating style for date: %v", err)
+		return 0
+	}
+
+	return style
+}
+
+// getXlsxStyle returns the style of the header or body cells.
+func getXlsxStyle(isHeader bool) *excelize.Style {
+	return &excelize.Style{
 		Font: &excelize.Font{
 			Size:      12,
 			Bold:      isHeader,
@@ -156,6 +272,8 @@ func SetXlsxStyle(file *excelize.File, isHeader bool) int {
 		},
 		Alignment: &excelize.Alignment{
 			Horizontal: "center",
+			Vertical:   "center",
+			WrapText:   isHeader,
 		},
 		Border: []excelize.Border{
 			{Type: "left", Style: 1, Color: BLACK_COLOR},
@@ -163,11 +281,5 @@ func SetXlsxStyle(file *excelize.File, isHeader bool) int {
 			{Type: "top", Style: 1, Color: BLACK_COLOR},
 			{Type: "bottom", Style: 1, Color: BLACK_COLOR},
 		},
-	})
-	if err != nil {
-		log.Printf("Error creating style for header: %v", err)
-		return 0
 	}
-
-	return style
 }